@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"runtime"
 	"sync"
 	"syscall"
 	"time"
@@ -20,6 +21,7 @@ import (
 	"github.com/netdata/go.d.plugin/agent/jobmgr"
 	"github.com/netdata/go.d.plugin/agent/module"
 	"github.com/netdata/go.d.plugin/agent/netdataapi"
+	"github.com/netdata/go.d.plugin/agent/promexporter"
 	"github.com/netdata/go.d.plugin/agent/safewriter"
 	"github.com/netdata/go.d.plugin/agent/vnodes"
 	"github.com/netdata/go.d.plugin/logger"
@@ -30,6 +32,12 @@ import (
 
 var isTerminal = isatty.IsTerminal(os.Stdout.Fd())
 
+// defaultMaxProcs is restored on reload if max_procs is removed from the configuration.
+var defaultMaxProcs = runtime.GOMAXPROCS(0)
+
+// discoveryStaleTimeout is the time the discovery has to re-emit the config sources after a reload.
+const discoveryStaleTimeout = time.Second * 30
+
 // Config is an Agent configuration.
 type Config struct {
 	Name              string
@@ -42,6 +50,10 @@ type Config struct {
 	ModuleRegistry    module.Registry
 	RunModule         string
 	MinUpdateEvery    int
+	// Out is where the collected data is written to, defaults to stdout.
+	Out io.Writer
+	// OutputFormat is the format of the collected data, the plugins.d protocol (default) or netdataapi.FormatJSONL.
+	OutputFormat string
 }
 
 // Agent represents orchestrator.
@@ -59,12 +71,25 @@ type Agent struct {
 	MinUpdateEvery    int
 	ModuleRegistry    module.Registry
 	Out               io.Writer
+	OutputFormat      string
 
 	api *netdataapi.API
+
+	reloadCh  chan struct{} // SIGHUP, apply the configuration changes to the running instance
+	restartCh chan struct{} // the configuration changes can't be applied without a restart
 }
 
 // New creates a new Agent.
 func New(cfg Config) *Agent {
+	out := cfg.Out
+	if out == nil {
+		out = safewriter.Stdout
+	}
+	// the plugins.d commands that are not the collected data (keepalive, DISABLE) are not a part of the JSON lines
+	api := netdataapi.New(out)
+	if cfg.OutputFormat == netdataapi.FormatJSONL {
+		api = netdataapi.New(io.Discard)
+	}
 	return &Agent{
 		Logger: logger.New().With(
 			slog.String("component", "agent"),
@@ -79,8 +104,11 @@ func New(cfg Config) *Agent {
 		RunModule:         cfg.RunModule,
 		MinUpdateEvery:    cfg.MinUpdateEvery,
 		ModuleRegistry:    module.DefaultRegistry,
-		Out:               safewriter.Stdout,
-		api:               netdataapi.New(safewriter.Stdout),
+		Out:               out,
+		OutputFormat:      cfg.OutputFormat,
+		api:               api,
+		reloadCh:          make(chan struct{}, 1),
+		restartCh:         make(chan struct{}, 1),
 	}
 }
 
@@ -99,19 +127,39 @@ func serve(a *Agent) {
 	var reload bool
 
 	for {
+		drain(a.reloadCh)
+		drain(a.restartCh)
+
 		ctx, cancel := context.WithCancel(context.Background())
 		ctx = context.WithValue(ctx, "reload", reload)
+		done := make(chan struct{})
 
 		wg.Add(1)
-		go func() { defer wg.Done(); a.run(ctx) }()
-
-		switch sig := <-ch; sig {
-		case syscall.SIGHUP:
-			a.Infof("received %s signal (%d). Restarting running instance", sig, sig)
-		default:
-			a.Infof("received %s signal (%d). Terminating...", sig, sig)
-			module.DontObsoleteCharts()
-			exit = true
+		go func() { defer wg.Done(); defer close(done); a.run(ctx) }()
+
+	wait:
+		for {
+			select {
+			case sig := <-ch:
+				if sig != syscall.SIGHUP {
+					a.Infof("received %s signal (%d). Terminating...", sig, sig)
+					module.DontObsoleteCharts()
+					exit = true
+					break wait
+				}
+				select {
+				case <-done:
+					// the instance didn't start (disabled, no modules), nothing to reload
+					a.Infof("received %s signal (%d). Restarting running instance", sig, sig)
+					break wait
+				default:
+					a.Infof("received %s signal (%d). Reloading configuration", sig, sig)
+					trigger(a.reloadCh)
+				}
+			case <-a.restartCh:
+				a.Info("restarting running instance")
+				break wait
+			}
 		}
 
 		cancel()
@@ -157,6 +205,8 @@ func (a *Agent) run(ctx context.Context) {
 		return
 	}
 
+	a.setMaxProcs(cfg.MaxProcs)
+
 	enabledModules := a.loadEnabledModules(cfg)
 	if len(enabledModules) == 0 {
 		a.Info("no modules to run")
@@ -183,7 +233,10 @@ func (a *Agent) run(ctx context.Context) {
 	jobsManager := jobmgr.NewManager()
 	jobsManager.PluginName = a.Name
 	jobsManager.Out = a.Out
+	jobsManager.OutputFormat = a.OutputFormat
 	jobsManager.Modules = enabledModules
+	jobsManager.MaxConcurrentCollections = cfg.MaxConcurrentCollections
+	jobsManager.TickJitter = a.collectJitter(cfg.CollectJitterMs)
 
 	// TODO: API will be changed in https://github.com/netdata/netdata/pull/16702
 	//if logger.Level.Enabled(slog.LevelDebug) {
@@ -221,6 +274,12 @@ func (a *Agent) run(ctx context.Context) {
 		}
 	}
 
+	var exporter *promexporter.Exporter
+	if cfg.PrometheusExporter.Enabled {
+		exporter = promexporter.New(cfg.PrometheusExporter)
+		jobsManager.Exporter = exporter
+	}
+
 	in := make(chan []*confgroup.Group)
 	var wg sync.WaitGroup
 
@@ -230,16 +289,65 @@ func (a *Agent) run(ctx context.Context) {
 	wg.Add(1)
 	go func() { defer wg.Done(); jobsManager.Run(ctx, in) }()
 
-	wg.Add(1)
-	go func() { defer wg.Done(); discoveryManager.Run(ctx, in) }()
-
 	if statusSaveManager != nil {
 		wg.Add(1)
 		go func() { defer wg.Done(); statusSaveManager.Run(ctx) }()
 	}
 
-	wg.Wait()
-	<-ctx.Done()
+	if exporter != nil {
+		wg.Add(1)
+		go func() { defer wg.Done(); exporter.Run(ctx) }()
+	}
+
+	inst := &instance{
+		cfg:           cfg,
+		discoveryConf: discCfg,
+		jobsManager:   jobsManager,
+		in:            in,
+		discovery:     startDiscovery(ctx, discoveryManager, in, nil, discoveryStaleTimeout),
+		staleTimeout:  discoveryStaleTimeout,
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			inst.discovery.stop()
+			wg.Wait()
+			return
+		case <-a.reloadCh:
+			if !a.reload(ctx, inst) {
+				trigger(a.restartCh)
+			}
+		}
+	}
+}
+
+func (a *Agent) setMaxProcs(n int) {
+	if n <= 0 {
+		n = defaultMaxProcs
+	}
+	if prev := runtime.GOMAXPROCS(n); prev != n {
+		a.Infof("changed GOMAXPROCS from %d to %d", prev, n)
+	}
+}
+
+// collectJitter returns the jobs tick jitter, every job limits it to a part of its data collection interval.
+func (a *Agent) collectJitter(ms int) time.Duration {
+	return max(time.Duration(ms)*time.Millisecond, 0)
+}
+
+func trigger(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func drain(ch chan struct{}) {
+	select {
+	case <-ch:
+	default:
+	}
 }
 
 func (a *Agent) keepAlive() {
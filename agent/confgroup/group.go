@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/netdata/go.d.plugin/agent/hostinfo"
+	"github.com/netdata/go.d.plugin/agent/internal/defaults"
 	"github.com/netdata/go.d.plugin/agent/module"
 
 	"github.com/ilyam8/hashstructure"
@@ -38,6 +39,45 @@ func (c Config) Hash() uint64            { return calcHash(c) }
 func (c Config) Source() string          { v, _ := c.get("__source__").(string); return v }
 func (c Config) Provider() string        { v, _ := c.get("__provider__").(string); return v }
 func (c Config) Vnode() string           { v, _ := c.get("vnode").(string); return v }
+func (c Config) CollectTimeout() int     { v, _ := c.get("collect_timeout").(int); return v }
+func (c Config) RestartStuck() bool      { v, _ := c.get("restart_stuck").(bool); return v }
+func (c Config) UpdateEveryMs() int      { v, _ := c.get("update_every_ms").(int); return v }
+
+// PhaseOffsetMs returns the phase offset in milliseconds, auto is true if it should be derived from the job name.
+func (c Config) PhaseOffsetMs() (ms int, auto bool) {
+	switch v := c.get("phase_offset_ms").(type) {
+	case int:
+		return v, false
+	case string:
+		return 0, v == "auto"
+	}
+	return 0, false
+}
+
+// Backoff returns the job backoff settings. The configs decoded from YAML have map[any]any values,
+// the ones decoded from JSON (dyncfg) have map[string]any values and float64 numbers.
+func (c Config) Backoff() module.Backoff {
+	var b module.Backoff
+	switch v := c.get("backoff").(type) {
+	case map[any]any:
+		b.Policy, _ = v["policy"].(string)
+		b.Max = toInt(v["max"])
+	case map[string]any:
+		b.Policy, _ = v["policy"].(string)
+		b.Max = toInt(v["max"])
+	}
+	return b
+}
+
+func toInt(v any) int {
+	switch v := v.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
 
 func (c Config) SetName(v string)     { c.set("name", v) }
 func (c Config) SetModule(v string)   { c.set("module", v) }
@@ -49,20 +89,37 @@ func (c Config) get(key string) interface{}        { return c[key] }
 
 func (c Config) Apply(def Default) {
 	if c.UpdateEvery() <= 0 {
-		v := firstPositive(def.UpdateEvery, module.UpdateEvery)
+		v := defaults.FirstPositive(def.UpdateEvery, module.UpdateEvery)
 		c.set("update_every", v)
 	}
 	if c.AutoDetectionRetry() <= 0 {
-		v := firstPositive(def.AutoDetectionRetry, module.AutoDetectionRetry)
+		v := defaults.FirstPositive(def.AutoDetectionRetry, module.AutoDetectionRetry)
 		c.set("autodetection_retry", v)
 	}
 	if c.Priority() <= 0 {
-		v := firstPositive(def.Priority, module.Priority)
+		v := defaults.FirstPositive(def.Priority, module.Priority)
 		c.set("priority", v)
 	}
+	if def.Backoff != (module.Backoff{}) {
+		b := c.Backoff()
+		c.set("backoff", map[any]any{
+			"policy": defaults.FirstNotEmpty(b.Policy, def.Backoff.Policy),
+			"max":    defaults.FirstPositive(b.Max, def.Backoff.Max),
+		})
+	}
 	if c.UpdateEvery() < def.MinUpdateEvery && def.MinUpdateEvery > 0 {
 		c.set("update_every", def.MinUpdateEvery)
 	}
+	if ms := c.UpdateEveryMs(); ms > 0 {
+		// netdata always passes the minimum update every (1 by default),
+		// only a value greater than 1 limits the millisecond intervals
+		if def.MinUpdateEvery > 1 && ms < def.MinUpdateEvery*1000 {
+			delete(c, "update_every_ms")
+		} else {
+			// the charts resolution is whole seconds
+			c.set("update_every", max(1, (ms+999)/1000))
+		}
+	}
 	if c.Name() == "" {
 		c.set("name", c.Module())
 	} else {
@@ -92,13 +149,6 @@ func calcHash(obj interface{}) uint64 {
 	return hash
 }
 
-func firstPositive(value int, others ...int) int {
-	if value > 0 || len(others) == 0 {
-		return value
-	}
-	return firstPositive(others[0], others[1:]...)
-}
-
 func urlResolveHostname(rawURL string) string {
 	if hostinfo.Hostname == "" || !strings.Contains(rawURL, "hostname") {
 		return rawURL
@@ -190,6 +190,57 @@ func TestConfig_SetProvider(t *testing.T) {
 	assert.Equal(t, cfg.Provider(), "name")
 }
 
+func TestConfig_PhaseOffsetMs(t *testing.T) {
+	tests := map[string]struct {
+		cfg      Config
+		wantMs   int
+		wantAuto bool
+	}{
+		"not set":        {cfg: Config{}},
+		"int":            {cfg: Config{"phase_offset_ms": 250}, wantMs: 250},
+		"auto":           {cfg: Config{"phase_offset_ms": "auto"}, wantAuto: true},
+		"unknown string": {cfg: Config{"phase_offset_ms": "random"}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ms, auto := test.cfg.PhaseOffsetMs()
+			assert.Equal(t, test.wantMs, ms)
+			assert.Equal(t, test.wantAuto, auto)
+		})
+	}
+}
+
+func TestConfig_Backoff(t *testing.T) {
+	tests := map[string]struct {
+		cfg  Config
+		want module.Backoff
+	}{
+		"not set": {
+			cfg:  Config{},
+			want: module.Backoff{},
+		},
+		"yaml": {
+			cfg:  Config{"backoff": map[any]any{"policy": module.BackoffExponential, "max": 300}},
+			want: module.Backoff{Policy: module.BackoffExponential, Max: 300},
+		},
+		"json": {
+			cfg:  Config{"backoff": map[string]any{"policy": module.BackoffExponential, "max": float64(300)}},
+			want: module.Backoff{Policy: module.BackoffExponential, Max: 300},
+		},
+		"wrong type": {
+			cfg:  Config{"backoff": module.BackoffExponential},
+			want: module.Backoff{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.cfg.Backoff())
+		})
+	}
+}
+
 func TestConfig_Apply(t *testing.T) {
 	const jobDef = 11
 	const applyDef = 22
@@ -251,6 +302,24 @@ func TestConfig_Apply(t *testing.T) {
 				"priority":            module.Priority,
 			},
 		},
+		"+job backoff +def backoff": {
+			def: Default{
+				Backoff: module.Backoff{Policy: module.BackoffLinear, Max: 300},
+			},
+			origCfg: Config{
+				"name":    "name",
+				"module":  "module",
+				"backoff": map[any]any{"policy": module.BackoffExponential},
+			},
+			expectedCfg: Config{
+				"name":                "name",
+				"module":              "module",
+				"update_every":        module.UpdateEvery,
+				"autodetection_retry": module.AutoDetectionRetry,
+				"priority":            module.Priority,
+				"backoff":             map[any]any{"policy": module.BackoffExponential, "max": 300},
+			},
+		},
 		"adjust update_every (update_every < min update every)": {
 			def: Default{
 				MinUpdateEvery: jobDef + 10,
@@ -285,6 +354,42 @@ func TestConfig_Apply(t *testing.T) {
 				"priority":            module.Priority,
 			},
 		},
+		"update_every_ms sets update_every (rounded up)": {
+			def: Default{
+				MinUpdateEvery: 1,
+			},
+			origCfg: Config{
+				"name":            "name",
+				"module":          "module",
+				"update_every":    jobDef,
+				"update_every_ms": 1500,
+			},
+			expectedCfg: Config{
+				"name":                "name",
+				"module":              "module",
+				"update_every":        2,
+				"update_every_ms":     1500,
+				"autodetection_retry": module.AutoDetectionRetry,
+				"priority":            module.Priority,
+			},
+		},
+		"remove update_every_ms (update_every_ms < min update every)": {
+			def: Default{
+				MinUpdateEvery: 2,
+			},
+			origCfg: Config{
+				"name":            "name",
+				"module":          "module",
+				"update_every_ms": 500,
+			},
+			expectedCfg: Config{
+				"name":                "name",
+				"module":              "module",
+				"update_every":        2,
+				"autodetection_retry": module.AutoDetectionRetry,
+				"priority":            module.Priority,
+			},
+		},
 		"set name to module name if name not set": {
 			def: Default{},
 			origCfg: Config{
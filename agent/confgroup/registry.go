@@ -2,13 +2,16 @@
 
 package confgroup
 
+import "github.com/netdata/go.d.plugin/agent/module"
+
 type Registry map[string]Default
 
 type Default struct {
-	MinUpdateEvery     int `yaml:"-"`
-	UpdateEvery        int `yaml:"update_every"`
-	AutoDetectionRetry int `yaml:"autodetection_retry"`
-	Priority           int `yaml:"priority"`
+	MinUpdateEvery     int            `yaml:"-"`
+	UpdateEvery        int            `yaml:"update_every"`
+	AutoDetectionRetry int            `yaml:"autodetection_retry"`
+	Priority           int            `yaml:"priority"`
+	Backoff            module.Backoff `yaml:"backoff"`
 }
 
 func (r Registry) Register(name string, def Default) {
@@ -5,6 +5,8 @@ package agent
 import (
 	"fmt"
 
+	"github.com/netdata/go.d.plugin/agent/promexporter"
+
 	"gopkg.in/yaml.v2"
 )
 
@@ -18,15 +20,18 @@ func defaultConfig() config {
 }
 
 type config struct {
-	Enabled    bool            `yaml:"enabled"`
-	DefaultRun bool            `yaml:"default_run"`
-	MaxProcs   int             `yaml:"max_procs"`
-	Modules    map[string]bool `yaml:"modules"`
+	Enabled                  bool                `yaml:"enabled"`
+	DefaultRun               bool                `yaml:"default_run"`
+	MaxProcs                 int                 `yaml:"max_procs"`
+	MaxConcurrentCollections int                 `yaml:"max_concurrent_collections"`
+	CollectJitterMs          int                 `yaml:"collect_jitter_ms"`
+	PrometheusExporter       promexporter.Config `yaml:"prometheus_exporter"`
+	Modules                  map[string]bool     `yaml:"modules"`
 }
 
 func (c *config) String() string {
-	return fmt.Sprintf("enabled '%v', default_run '%v', max_procs '%d'",
-		c.Enabled, c.DefaultRun, c.MaxProcs)
+	return fmt.Sprintf("enabled '%v', default_run '%v', max_procs '%d', max_concurrent_collections '%d', collect_jitter_ms '%d'",
+		c.Enabled, c.DefaultRun, c.MaxProcs, c.MaxConcurrentCollections, c.CollectJitterMs)
 }
 
 func (c *config) isExplicitlyEnabled(moduleName string) bool {
@@ -60,7 +65,7 @@ func (c *config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 	for key, value := range m {
 		switch key {
-		case "enabled", "default_run", "max_procs", "modules":
+		case "enabled", "default_run", "max_procs", "max_concurrent_collections", "collect_jitter_ms", "prometheus_exporter", "modules":
 			continue
 		}
 		var b bool
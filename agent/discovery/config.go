@@ -8,19 +8,21 @@ import (
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/discovery/dummy"
 	"github.com/netdata/go.d.plugin/agent/discovery/file"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd"
 )
 
 type Config struct {
 	Registry confgroup.Registry
 	File     file.Config
 	Dummy    dummy.Config
+	SD       sd.Config
 }
 
 func validateConfig(cfg Config) error {
 	if len(cfg.Registry) == 0 {
 		return errors.New("empty config registry")
 	}
-	if len(cfg.File.Read)+len(cfg.File.Watch) == 0 && len(cfg.Dummy.Names) == 0 {
+	if len(cfg.File.Read)+len(cfg.File.Watch) == 0 && len(cfg.Dummy.Names) == 0 && len(cfg.SD.ConfDir) == 0 {
 		return errors.New("discoverers not set")
 	}
 	return nil
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 
 	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/internal/defaults"
+	"github.com/netdata/go.d.plugin/agent/module"
 
 	"gopkg.in/yaml.v2"
 )
@@ -117,20 +119,17 @@ func cfgFormat(bs []byte) format {
 
 func mergeDef(a, b confgroup.Default) confgroup.Default {
 	return confgroup.Default{
-		MinUpdateEvery:     firstPositive(a.MinUpdateEvery, b.MinUpdateEvery),
-		UpdateEvery:        firstPositive(a.UpdateEvery, b.UpdateEvery),
-		AutoDetectionRetry: firstPositive(a.AutoDetectionRetry, b.AutoDetectionRetry),
-		Priority:           firstPositive(a.Priority, b.Priority),
+		MinUpdateEvery:     defaults.FirstPositive(a.MinUpdateEvery, b.MinUpdateEvery),
+		UpdateEvery:        defaults.FirstPositive(a.UpdateEvery, b.UpdateEvery),
+		AutoDetectionRetry: defaults.FirstPositive(a.AutoDetectionRetry, b.AutoDetectionRetry),
+		Priority:           defaults.FirstPositive(a.Priority, b.Priority),
+		Backoff: module.Backoff{
+			Policy: defaults.FirstNotEmpty(a.Backoff.Policy, b.Backoff.Policy),
+			Max:    defaults.FirstPositive(a.Backoff.Max, b.Backoff.Max),
+		},
 	}
 }
 
-func firstPositive(value int, others ...int) int {
-	if value > 0 || len(others) == 0 {
-		return value
-	}
-	return firstPositive(others[0], others[1:]...)
-}
-
 func fileName(path string) string {
 	_, file := filepath.Split(path)
 	ext := filepath.Ext(path)
@@ -13,6 +13,7 @@ import (
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/discovery/dummy"
 	"github.com/netdata/go.d.plugin/agent/discovery/file"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd"
 	"github.com/netdata/go.d.plugin/logger"
 )
 
@@ -103,6 +104,15 @@ func (m *Manager) registerDiscoverers(cfg Config) error {
 		m.Add(d)
 	}
 
+	if len(cfg.SD.ConfDir) > 0 {
+		cfg.SD.Registry = cfg.Registry
+		d, err := sd.NewServiceDiscovery(cfg.SD)
+		if err != nil {
+			return err
+		}
+		m.Add(d)
+	}
+
 	if len(m.discoverers) == 0 {
 		return errors.New("zero registered discoverers")
 	}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/discovery/file"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -26,6 +27,12 @@ func TestNewManager(t *testing.T) {
 				File:     file.Config{Read: []string{"path"}},
 			},
 		},
+		"valid config, service discovery": {
+			cfg: Config{
+				Registry: confgroup.Registry{"module1": confgroup.Default{}},
+				SD:       sd.Config{ConfDir: []string{"dir"}},
+			},
+		},
 		"invalid config, registry not set": {
 			cfg: Config{
 				File: file.Config{Read: []string{"path"}},
@@ -4,7 +4,13 @@ package sd
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/netdata/go.d.plugin/logger"
+
+	"github.com/fsnotify/fsnotify"
 	"github.com/ilyam8/hashstructure"
 )
 
@@ -22,3 +28,151 @@ func (c *ConfigFile) Hash() uint64 {
 	h, _ := hashstructure.Hash(c, nil)
 	return h
 }
+
+var confFilePatterns = []string{"*.conf", "*.yaml", "*.yml"}
+
+func newConfFileProvider(log *logger.Logger, dirs []string) *confFileProvider {
+	return &confFileProvider{
+		Logger:       log,
+		dirs:         dirs,
+		ch:           make(chan ConfigFile),
+		cache:        make(map[string]uint64),
+		refreshEvery: time.Minute,
+	}
+}
+
+// confFileProvider reads the pipeline config files from the conf dirs and watches them for changes.
+// A removed file is sent as a ConfigFile with no data.
+type confFileProvider struct {
+	*logger.Logger
+
+	dirs         []string
+	ch           chan ConfigFile
+	cache        map[string]uint64 // [source]data hash
+	refreshEvery time.Duration
+}
+
+func (p *confFileProvider) Configs() chan ConfigFile {
+	return p.ch
+}
+
+func (p *confFileProvider) Run(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		p.Errorf("fsnotify watcher initialization: %v", err)
+	} else {
+		defer func() { _ = watcher.Close() }()
+		for _, dir := range p.dirs {
+			if err := watcher.Add(dir); err != nil && !os.IsNotExist(err) {
+				p.Warningf("watch '%s': %v", dir, err)
+			}
+		}
+	}
+
+	p.refresh(ctx)
+
+	tk := time.NewTicker(p.refreshEvery)
+	defer tk.Stop()
+
+	var events chan fsnotify.Event
+	var errs chan error
+	if watcher != nil {
+		events, errs = watcher.Events, watcher.Errors
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			p.refresh(ctx)
+		case event := <-events:
+			if event.Name == "" || event.Op == fsnotify.Chmod || !isConfFile(event.Name) {
+				break
+			}
+			if event.Has(fsnotify.Rename) {
+				// editors often save files by renaming, give them time to write the new file
+				time.Sleep(time.Millisecond * 100)
+			}
+			p.refresh(ctx)
+		case err := <-errs:
+			if err != nil {
+				p.Warningf("watch: %v", err)
+			}
+		}
+	}
+}
+
+func (p *confFileProvider) refresh(ctx context.Context) {
+	seen := make(map[string]bool)
+
+	for _, path := range p.listFiles() {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			p.Warningf("read '%s': %v", path, err)
+			continue
+		}
+		seen[path] = true
+
+		cf := ConfigFile{Source: path, Data: bs}
+		if hash, ok := p.cache[path]; ok && hash == cf.Hash() {
+			continue
+		}
+		if !p.send(ctx, cf) {
+			return
+		}
+		p.cache[path] = cf.Hash()
+	}
+
+	for path := range p.cache {
+		if seen[path] {
+			continue
+		}
+		if !p.send(ctx, ConfigFile{Source: path}) {
+			return
+		}
+		delete(p.cache, path)
+	}
+}
+
+// listFiles returns the config files, the first dir wins if the same file name is found in several dirs.
+func (p *confFileProvider) listFiles() []string {
+	var files []string
+	names := make(map[string]bool)
+
+	for _, dir := range p.dirs {
+		for _, pattern := range confFilePatterns {
+			matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+			for _, path := range matches {
+				if names[filepath.Base(path)] {
+					continue
+				}
+				if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+					continue
+				}
+				names[filepath.Base(path)] = true
+				files = append(files, path)
+			}
+		}
+	}
+
+	return files
+}
+
+func (p *confFileProvider) send(ctx context.Context, cf ConfigFile) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case p.ch <- cf:
+		return true
+	}
+}
+
+func isConfFile(path string) bool {
+	for _, pattern := range confFilePatterns {
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package sd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/netdata/go.d.plugin/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfFileProvider_Run(t *testing.T) {
+	userDir, stockDir := t.TempDir(), t.TempDir()
+	writeFile := func(dir, name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	userK8s := writeFile(userDir, "k8s.conf", "name: user")
+	writeFile(stockDir, "k8s.conf", "name: stock")
+	stockNet := writeFile(stockDir, "net.conf", "name: net")
+	writeFile(stockDir, "README.md", "not a config")
+
+	prov := newConfFileProvider(logger.New(), []string{userDir, stockDir})
+	prov.refreshEvery = time.Millisecond * 100
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go prov.Run(ctx)
+
+	recv := func() ConfigFile {
+		select {
+		case cf := <-prov.Configs():
+			return cf
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for a config file")
+			return ConfigFile{}
+		}
+	}
+	recvAll := func(n int) map[string]string {
+		res := make(map[string]string)
+		for i := 0; i < n; i++ {
+			cf := recv()
+			res[cf.Source] = string(cf.Data)
+		}
+		return res
+	}
+
+	// the user file overrides the stock file with the same name
+	assert.Equal(t, map[string]string{userK8s: "name: user", stockNet: "name: net"}, recvAll(2))
+
+	writeFile(stockDir, "net.conf", "name: net2")
+	assert.Equal(t, map[string]string{stockNet: "name: net2"}, recvAll(1))
+
+	require.NoError(t, os.Remove(userK8s))
+	assert.Equal(t, map[string]string{userK8s: "", filepath.Join(stockDir, "k8s.conf"): "name: stock"}, recvAll(2))
+}
@@ -0,0 +1,8 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package docker
+
+type Config struct {
+	Address string `yaml:"address"`
+	Tags    string `yaml:"tags"`
+}
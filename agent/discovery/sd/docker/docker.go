@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/logger"
+
+	"github.com/docker/docker/api/types"
+	docker "github.com/docker/docker/client"
+	"github.com/ilyam8/hashstructure"
+)
+
+const defaultAddress = "unix:///var/run/docker.sock"
+
+type targetGroup struct {
+	source  string
+	targets []model.Target
+}
+
+func (g *targetGroup) Provider() string        { return "sd:docker" }
+func (g *targetGroup) Source() string          { return fmt.Sprintf("%s(%s)", g.Provider(), g.source) }
+func (g *targetGroup) Targets() []model.Target { return g.targets }
+
+type target struct {
+	model.Base `hash:"ignore"`
+
+	hash uint64
+	tuid string
+
+	ID           string
+	Name         string
+	Image        string
+	Command      string
+	Labels       map[string]any
+	NetworkMode  string
+	Networks     map[string]any // network name => container IP address
+	IPAddress    string
+	Address      string
+	PrivatePort  string
+	PublicPort   string
+	PublicPortIP string
+	PortProtocol string
+}
+
+func (t *target) Hash() uint64 { return t.hash }
+func (t *target) TUID() string { return t.tuid }
+
+func NewDiscoverer(cfg Config) (*Discoverer, error) {
+	tags, err := model.ParseTags(cfg.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("parse tags: %v", err)
+	}
+
+	if cfg.Address == "" {
+		cfg.Address = defaultAddress
+	}
+
+	d := &Discoverer{
+		Logger: logger.New().With(
+			slog.String("component", "discovery sd docker"),
+		),
+		address:  cfg.Address,
+		interval: time.Second * 10,
+		timeout:  time.Second * 5,
+		newClient: func(addr string) (dockerClient, error) {
+			return docker.NewClientWithOpts(docker.WithHost(addr))
+		},
+	}
+	d.Tags().Merge(tags)
+
+	return d, nil
+}
+
+type (
+	Discoverer struct {
+		*logger.Logger
+		model.Base
+
+		address    string
+		interval   time.Duration
+		timeout    time.Duration
+		newClient  func(addr string) (dockerClient, error)
+		client     dockerClient
+		negotiated bool
+	}
+	dockerClient interface {
+		Ping(context.Context) (types.Ping, error)
+		NegotiateAPIVersionPing(types.Ping)
+		ContainerList(context.Context, types.ContainerListOptions) ([]types.Container, error)
+		Close() error
+	}
+)
+
+func (d *Discoverer) String() string {
+	return "sd:docker"
+}
+
+func (d *Discoverer) Discover(ctx context.Context, in chan<- []model.TargetGroup) {
+	d.Info("instance is started")
+	defer d.Info("instance is stopped")
+
+	client, err := d.newClient(d.address)
+	if err != nil {
+		d.Errorf("create docker client: %v", err)
+		return
+	}
+	d.client = client
+	defer func() { _ = d.client.Close() }()
+
+	// the daemon may be not running yet, the discovery is retried on every tick
+	if err := d.discoverContainers(ctx, in); err != nil {
+		d.Warning(err)
+	}
+
+	tk := time.NewTicker(d.interval)
+	defer tk.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			if err := d.discoverContainers(ctx, in); err != nil {
+				d.Warning(err)
+			}
+		}
+	}
+}
+
+func (d *Discoverer) discoverContainers(ctx context.Context, in chan<- []model.TargetGroup) error {
+	listCtx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	if !d.negotiated {
+		ping, err := d.client.Ping(listCtx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("ping '%s': %v", d.address, err)
+		}
+		d.client.NegotiateAPIVersionPing(ping)
+		d.negotiated = true
+	}
+
+	containers, err := d.client.ContainerList(listCtx, types.ContainerListOptions{})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("list containers on '%s': %v", d.address, err)
+	}
+
+	tgg := &targetGroup{source: d.address}
+	for _, cntr := range containers {
+		tgg.targets = append(tgg.targets, d.buildTargets(cntr)...)
+	}
+
+	select {
+	case <-ctx.Done():
+	case in <- []model.TargetGroup{tgg}:
+	}
+	return nil
+}
+
+func (d *Discoverer) buildTargets(cntr types.Container) []model.Target {
+	name := containerName(cntr)
+	networks, ip := containerNetworks(cntr)
+
+	var tgts []model.Target
+	seen := make(map[string]bool)
+
+	for _, port := range cntr.Ports {
+		privatePort := strconv.FormatUint(uint64(port.PrivatePort), 10)
+		proto := strings.ToLower(port.Type)
+
+		// a port published on both IPv4 and IPv6 is listed twice
+		key := proto + "/" + privatePort
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		tgt := &target{
+			tuid:         fmt.Sprintf("%s_%s_%s", name, proto, privatePort),
+			ID:           cntr.ID,
+			Name:         name,
+			Image:        cntr.Image,
+			Command:      cntr.Command,
+			Labels:       mapAny(cntr.Labels),
+			NetworkMode:  cntr.HostConfig.NetworkMode,
+			Networks:     networks,
+			IPAddress:    ip,
+			PrivatePort:  privatePort,
+			PortProtocol: proto,
+		}
+		if ip != "" {
+			tgt.Address = net.JoinHostPort(ip, privatePort)
+		}
+		if port.PublicPort != 0 {
+			tgt.PublicPort = strconv.FormatUint(uint64(port.PublicPort), 10)
+			tgt.PublicPortIP = port.IP
+		}
+
+		hash, err := calcHash(tgt)
+		if err != nil {
+			continue
+		}
+		tgt.hash = hash
+		tgt.Tags().Merge(d.Tags())
+
+		tgts = append(tgts, tgt)
+	}
+
+	return tgts
+}
+
+func containerName(cntr types.Container) string {
+	if len(cntr.Names) == 0 {
+		return cntr.ID
+	}
+	return strings.TrimPrefix(cntr.Names[0], "/")
+}
+
+// containerNetworks returns the container IP address in every network it is attached to, and the primary address:
+// the one in the network of the container network mode, or in the first (alphabetically) network otherwise.
+func containerNetworks(cntr types.Container) (map[string]any, string) {
+	if cntr.NetworkSettings == nil || len(cntr.NetworkSettings.Networks) == 0 {
+		return nil, ""
+	}
+
+	networks := make(map[string]any)
+	var names []string
+	for name, ep := range cntr.NetworkSettings.Networks {
+		if ep == nil || ep.IPAddress == "" {
+			continue
+		}
+		networks[name] = ep.IPAddress
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, ""
+	}
+
+	if ip, ok := networks[cntr.HostConfig.NetworkMode]; ok {
+		return networks, ip.(string)
+	}
+	sort.Strings(names)
+	return networks, networks[names[0]].(string)
+}
+
+func mapAny(src map[string]string) map[string]any {
+	if src == nil {
+		return nil
+	}
+	m := make(map[string]any, len(src))
+	for k, v := range src {
+		m[k] = v
+	}
+	return m
+}
+
+func calcHash(obj any) (uint64, error) {
+	return hashstructure.Hash(obj, nil)
+}
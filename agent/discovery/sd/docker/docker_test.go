@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDiscoverer(t *testing.T) {
+	tests := map[string]struct {
+		cfg         Config
+		wantAddress string
+		wantErr     bool
+	}{
+		"default address": {
+			cfg:         Config{Tags: "docker"},
+			wantAddress: defaultAddress,
+		},
+		"custom address": {
+			cfg:         Config{Address: "tcp://127.0.0.1:2375", Tags: "docker"},
+			wantAddress: "tcp://127.0.0.1:2375",
+		},
+		"invalid tags": {
+			cfg:     Config{Tags: "docker $"},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, err := NewDiscoverer(test.cfg)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.wantAddress, d.address)
+			}
+		})
+	}
+}
+
+func TestDiscoverer_Discover(t *testing.T) {
+	tests := map[string]struct {
+		client           *mockClient
+		wantTargetGroups []model.TargetGroup
+	}{
+		"containers with ports": {
+			client: &mockClient{containers: []types.Container{
+				newContainer("nginx", "nginx:latest", "bridge",
+					map[string]string{"bridge": "172.17.0.2"},
+					types.Port{IP: "0.0.0.0", PrivatePort: 80, PublicPort: 8080, Type: "tcp"},
+					types.Port{IP: "::", PrivatePort: 80, PublicPort: 8080, Type: "tcp"},
+					types.Port{PrivatePort: 443, Type: "tcp"},
+				),
+				newContainer("redis", "redis:7", "app",
+					map[string]string{"app": "172.18.0.3", "bridge": "172.17.0.3"},
+					types.Port{PrivatePort: 6379, Type: "tcp"},
+				),
+				newContainer("no-ports", "busybox", "bridge",
+					map[string]string{"bridge": "172.17.0.4"},
+				),
+			}},
+			wantTargetGroups: []model.TargetGroup{&targetGroup{
+				source: defaultAddress,
+				targets: []model.Target{
+					withHash(&target{
+						tuid:         "nginx_tcp_80",
+						ID:           "nginx-id",
+						Name:         "nginx",
+						Image:        "nginx:latest",
+						Command:      "run nginx",
+						Labels:       map[string]any{"app": "nginx"},
+						NetworkMode:  "bridge",
+						Networks:     map[string]any{"bridge": "172.17.0.2"},
+						IPAddress:    "172.17.0.2",
+						Address:      "172.17.0.2:80",
+						PrivatePort:  "80",
+						PublicPort:   "8080",
+						PublicPortIP: "0.0.0.0",
+						PortProtocol: "tcp",
+					}),
+					withHash(&target{
+						tuid:         "nginx_tcp_443",
+						ID:           "nginx-id",
+						Name:         "nginx",
+						Image:        "nginx:latest",
+						Command:      "run nginx",
+						Labels:       map[string]any{"app": "nginx"},
+						NetworkMode:  "bridge",
+						Networks:     map[string]any{"bridge": "172.17.0.2"},
+						IPAddress:    "172.17.0.2",
+						Address:      "172.17.0.2:443",
+						PrivatePort:  "443",
+						PortProtocol: "tcp",
+					}),
+					withHash(&target{
+						tuid:         "redis_tcp_6379",
+						ID:           "redis-id",
+						Name:         "redis",
+						Image:        "redis:7",
+						Command:      "run redis",
+						Labels:       map[string]any{"app": "redis"},
+						NetworkMode:  "app",
+						Networks:     map[string]any{"app": "172.18.0.3", "bridge": "172.17.0.3"},
+						IPAddress:    "172.18.0.3",
+						Address:      "172.18.0.3:6379",
+						PrivatePort:  "6379",
+						PortProtocol: "tcp",
+					}),
+				},
+			}},
+		},
+		"no containers": {
+			client: &mockClient{},
+			wantTargetGroups: []model.TargetGroup{&targetGroup{
+				source: defaultAddress,
+			}},
+		},
+		"daemon is not up at start": {
+			client: &mockClient{pingErrors: 2, listErrors: 1, containers: []types.Container{
+				newContainer("redis", "redis:7", "bridge",
+					map[string]string{"bridge": "172.17.0.3"},
+					types.Port{PrivatePort: 6379, Type: "tcp"},
+				),
+			}},
+			wantTargetGroups: []model.TargetGroup{&targetGroup{
+				source: defaultAddress,
+				targets: []model.Target{
+					withHash(&target{
+						tuid:         "redis_tcp_6379",
+						ID:           "redis-id",
+						Name:         "redis",
+						Image:        "redis:7",
+						Command:      "run redis",
+						Labels:       map[string]any{"app": "redis"},
+						NetworkMode:  "bridge",
+						Networks:     map[string]any{"bridge": "172.17.0.3"},
+						IPAddress:    "172.17.0.3",
+						Address:      "172.17.0.3:6379",
+						PrivatePort:  "6379",
+						PortProtocol: "tcp",
+					}),
+				},
+			}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, err := NewDiscoverer(Config{Tags: "docker"})
+			require.NoError(t, err)
+			d.newClient = func(string) (dockerClient, error) { return test.client, nil }
+			d.interval = time.Millisecond * 50
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			in := make(chan []model.TargetGroup)
+			done := make(chan struct{})
+			go func() { defer close(done); d.Discover(ctx, in) }()
+
+			var tggs []model.TargetGroup
+			select {
+			case tggs = <-in:
+			case <-done:
+			case <-time.After(time.Second * 3):
+				t.Log("discovery timed out")
+			}
+
+			assert.Equal(t, test.wantTargetGroups, tggs)
+
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(time.Second * 3):
+				assert.Fail(t, "discovery hasn't finished after cancel")
+			}
+			assert.True(t, test.client.closeCalled, "client Close() called")
+			assert.Equal(t, 1, test.client.negotiateCalls, "API version negotiations")
+		})
+	}
+}
+
+func newContainer(name, image, networkMode string, networks map[string]string, ports ...types.Port) types.Container {
+	cntr := types.Container{
+		ID:      name + "-id",
+		Names:   []string{"/" + name},
+		Image:   image,
+		Command: "run " + name,
+		Labels:  map[string]string{"app": name},
+		Ports:   ports,
+		NetworkSettings: &types.SummaryNetworkSettings{
+			Networks: make(map[string]*network.EndpointSettings),
+		},
+	}
+	cntr.HostConfig.NetworkMode = networkMode
+	for name, ip := range networks {
+		cntr.NetworkSettings.Networks[name] = &network.EndpointSettings{IPAddress: ip}
+	}
+	return cntr
+}
+
+func withHash(tgt *target) *target {
+	tgt.hash, _ = calcHash(tgt)
+	tags, _ := model.ParseTags("docker")
+	tgt.Tags().Merge(tags)
+	return tgt
+}
+
+type mockClient struct {
+	pingErrors     int
+	listErrors     int
+	containers     []types.Container
+	negotiateCalls int
+	closeCalled    bool
+}
+
+func (m *mockClient) Ping(context.Context) (types.Ping, error) {
+	if m.pingErrors > 0 {
+		m.pingErrors--
+		return types.Ping{}, errors.New("mock.Ping() error")
+	}
+	return types.Ping{APIVersion: "1.43"}, nil
+}
+
+func (m *mockClient) NegotiateAPIVersionPing(types.Ping) {
+	m.negotiateCalls++
+}
+
+func (m *mockClient) ContainerList(context.Context, types.ContainerListOptions) ([]types.Container, error) {
+	if m.listErrors > 0 {
+		m.listErrors--
+		return nil, errors.New("mock.ContainerList() error")
+	}
+	return m.containers, nil
+}
+
+func (m *mockClient) Close() error {
+	m.closeCalled = true
+	return nil
+}
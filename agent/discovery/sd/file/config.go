@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package file
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+type Config struct {
+	Files []string `yaml:"files"` // glob patterns
+	Tags  string   `yaml:"tags"`
+}
+
+func validateConfig(cfg Config) error {
+	if len(cfg.Files) == 0 {
+		return errors.New("'files' not set")
+	}
+	for _, pattern := range cfg.Files {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad pattern '%s': %v", pattern, err)
+		}
+	}
+	return nil
+}
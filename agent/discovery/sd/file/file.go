@@ -0,0 +1,286 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package file
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/logger"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ilyam8/hashstructure"
+	"gopkg.in/yaml.v2"
+)
+
+// tagsKey is the target key with the target specific tags, it is not a part of the target fields.
+const tagsKey = "tags"
+
+// the target metadata keys, the target fields can't start with "__"
+const (
+	metaHashKey = "__hash__"
+	metaTUIDKey = "__tuid__"
+	metaTagsKey = "__tags__"
+)
+
+type targetGroup struct {
+	source  string
+	targets []model.Target
+}
+
+func (g *targetGroup) Provider() string        { return "sd:file" }
+func (g *targetGroup) Source() string          { return fmt.Sprintf("%s(%s)", g.Provider(), g.source) }
+func (g *targetGroup) Targets() []model.Target { return g.targets }
+
+// target is a map, so the templates access the target fields directly (e.g. '{{.address}}').
+type target map[string]any
+
+func (t target) Hash() uint64 { v, _ := t[metaHashKey].(uint64); return v }
+func (t target) TUID() string { v, _ := t[metaTUIDKey].(string); return v }
+func (t target) Tags() model.Tags {
+	tags, ok := t[metaTagsKey].(model.Tags)
+	if !ok {
+		tags = model.NewTags()
+		t[metaTagsKey] = tags
+	}
+	return tags
+}
+
+func NewDiscoverer(cfg Config) (*Discoverer, error) {
+	if err := validateConfig(cfg); err != nil {
+		return nil, fmt.Errorf("file discoverer config validation: %v", err)
+	}
+
+	tags, err := model.ParseTags(cfg.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("parse tags: %v", err)
+	}
+
+	d := &Discoverer{
+		Logger: logger.New().With(
+			slog.String("component", "discovery sd file"),
+		),
+		patterns:     cfg.Files,
+		cache:        make(map[string]uint64),
+		refreshEvery: time.Minute,
+	}
+	d.Tags().Merge(tags)
+
+	return d, nil
+}
+
+// Discoverer reads the targets from the YAML (or JSON) files and watches the files for changes.
+// A file is a list of targets, a target is a set of arbitrary fields and optional (space separated) 'tags'.
+// The field names starting with "__" are reserved.
+// Every file is a target group, a removed file is sent as a group with no targets.
+type Discoverer struct {
+	*logger.Logger
+	model.Base
+
+	patterns     []string
+	cache        map[string]uint64 // [path]data hash
+	refreshEvery time.Duration
+}
+
+func (d *Discoverer) String() string {
+	return "sd:file"
+}
+
+func (d *Discoverer) Discover(ctx context.Context, in chan<- []model.TargetGroup) {
+	d.Info("instance is started")
+	defer d.Info("instance is stopped")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		d.Errorf("fsnotify watcher initialization: %v", err)
+	} else {
+		defer func() { _ = watcher.Close() }()
+		for _, dir := range d.dirs() {
+			if err := watcher.Add(dir); err != nil && !os.IsNotExist(err) {
+				d.Warningf("watch '%s': %v", dir, err)
+			}
+		}
+	}
+
+	d.refresh(ctx, in)
+
+	tk := time.NewTicker(d.refreshEvery)
+	defer tk.Stop()
+
+	var events chan fsnotify.Event
+	var errs chan error
+	if watcher != nil {
+		events, errs = watcher.Events, watcher.Errors
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			d.refresh(ctx, in)
+		case event := <-events:
+			if event.Name == "" || event.Op == fsnotify.Chmod || !d.isTargetFile(event.Name) {
+				break
+			}
+			if event.Has(fsnotify.Rename) {
+				// editors often save files by renaming, give them time to write the new file
+				time.Sleep(time.Millisecond * 100)
+			}
+			d.refresh(ctx, in)
+		case err := <-errs:
+			if err != nil {
+				d.Warningf("watch: %v", err)
+			}
+		}
+	}
+}
+
+func (d *Discoverer) refresh(ctx context.Context, in chan<- []model.TargetGroup) {
+	var tggs []model.TargetGroup
+	seen := make(map[string]bool)
+
+	for _, path := range d.listFiles() {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			d.Warningf("read '%s': %v", path, err)
+			continue
+		}
+		seen[path] = true
+
+		hash, _ := hashstructure.Hash(bs, nil)
+		if h, ok := d.cache[path]; ok && h == hash {
+			continue
+		}
+
+		tgg, err := d.parseFile(path, bs)
+		if err != nil {
+			// keep the targets from the previous (valid) version of the file
+			d.Warningf("parse '%s': %v", path, err)
+			continue
+		}
+		d.cache[path] = hash
+		tggs = append(tggs, tgg)
+	}
+
+	for path := range d.cache {
+		if !seen[path] {
+			delete(d.cache, path)
+			tggs = append(tggs, &targetGroup{source: path})
+		}
+	}
+
+	if len(tggs) == 0 {
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+	case in <- tggs:
+	}
+}
+
+func (d *Discoverer) parseFile(path string, bs []byte) (*targetGroup, error) {
+	var items []map[string]any
+	if err := yaml.Unmarshal(bs, &items); err != nil {
+		return nil, err
+	}
+
+	tgg := &targetGroup{source: path}
+
+	for i, item := range items {
+		if len(item) == 0 {
+			continue
+		}
+
+		tags := model.NewTags()
+		if v, ok := item[tagsKey]; ok {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("target[%d]: 'tags' is not a string", i+1)
+			}
+			var err error
+			if tags, err = model.ParseTags(s); err != nil {
+				return nil, fmt.Errorf("target[%d]: %v", i+1, err)
+			}
+		}
+
+		for key := range item {
+			if strings.HasPrefix(key, "__") {
+				return nil, fmt.Errorf("target[%d]: key '%s' is reserved", i+1, key)
+			}
+		}
+
+		hash, err := calcHash(item)
+		if err != nil {
+			return nil, fmt.Errorf("target[%d]: %v", i+1, err)
+		}
+
+		tgt := target(item)
+		delete(tgt, tagsKey)
+		tgt[metaHashKey] = hash
+		tgt[metaTUIDKey] = fmt.Sprintf("%s_%d_%d", filepath.Base(path), i+1, hash)
+		tgt.Tags().Merge(d.Tags())
+		tgt.Tags().Merge(tags)
+
+		tgg.targets = append(tgg.targets, tgt)
+	}
+
+	return tgg, nil
+}
+
+// listFiles returns the regular files matching the patterns.
+func (d *Discoverer) listFiles() []string {
+	var files []string
+	seen := make(map[string]bool)
+
+	for _, pattern := range d.patterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			if seen[path] {
+				continue
+			}
+			if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+				continue
+			}
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	return files
+}
+
+func (d *Discoverer) dirs() []string {
+	var dirs []string
+	seen := make(map[string]bool)
+
+	for _, pattern := range d.patterns {
+		dir := filepath.Dir(pattern)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+func (d *Discoverer) isTargetFile(path string) bool {
+	for _, pattern := range d.patterns {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func calcHash(obj any) (uint64, error) {
+	return hashstructure.Hash(obj, nil)
+}
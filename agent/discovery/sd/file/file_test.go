@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package file
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDiscoverer(t *testing.T) {
+	tests := map[string]struct {
+		cfg     Config
+		wantErr bool
+	}{
+		"valid config": {
+			cfg: Config{Files: []string{"/etc/netdata/cmdb/*.yaml"}, Tags: "cmdb"},
+		},
+		"files not set": {
+			cfg:     Config{Tags: "cmdb"},
+			wantErr: true,
+		},
+		"bad pattern": {
+			cfg:     Config{Files: []string{"/etc/netdata/cmdb/[.yaml"}, Tags: "cmdb"},
+			wantErr: true,
+		},
+		"invalid tags": {
+			cfg:     Config{Files: []string{"/etc/netdata/cmdb/*.yaml"}, Tags: "cmdb $"},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewDiscoverer(test.cfg)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDiscoverer_Discover(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "hosts.yaml")
+	jsonFile := filepath.Join(dir, "hosts.json")
+	writeFile := func(path, content string) {
+		// write and rename, so the discoverer doesn't read a partially written file
+		tmp := path + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, []byte(content), 0644))
+		require.NoError(t, os.Rename(tmp, path))
+	}
+
+	writeFile(yamlFile, `
+- name: pg1
+  address: 10.0.0.1:5432
+  tags: "postgres prod"
+- name: redis1
+  address: 10.0.0.2:6379
+`)
+	writeFile(jsonFile, `[{"name": "web1", "port": 80}]`)
+	writeFile(filepath.Join(dir, "invalid.yaml"), `this is not a list`)
+
+	d, err := NewDiscoverer(Config{
+		Files: []string{filepath.Join(dir, "*.yaml"), filepath.Join(dir, "*.json")},
+		Tags:  "cmdb",
+	})
+	require.NoError(t, err)
+	d.refreshEvery = time.Millisecond * 100
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan []model.TargetGroup)
+	done := make(chan struct{})
+	go func() { defer close(done); d.Discover(ctx, in) }()
+
+	assert.Equal(t, []model.TargetGroup{
+		newTargetGroup(yamlFile,
+			newTarget(t, yamlFile, 1, map[string]any{"name": "pg1", "address": "10.0.0.1:5432"}, "postgres prod"),
+			newTarget(t, yamlFile, 2, map[string]any{"name": "redis1", "address": "10.0.0.2:6379"}, ""),
+		),
+		newTargetGroup(jsonFile,
+			newTarget(t, jsonFile, 1, map[string]any{"name": "web1", "port": 80}, ""),
+		),
+	}, receive(t, in), "initial read")
+
+	writeFile(yamlFile, `
+- name: pg1
+  address: 10.0.0.1:5433
+`)
+	assert.Equal(t, []model.TargetGroup{
+		newTargetGroup(yamlFile,
+			newTarget(t, yamlFile, 1, map[string]any{"name": "pg1", "address": "10.0.0.1:5433"}, ""),
+		),
+	}, receive(t, in), "changed file")
+
+	require.NoError(t, os.Remove(jsonFile))
+	assert.Equal(t, []model.TargetGroup{
+		newTargetGroup(jsonFile),
+	}, receive(t, in), "removed file")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 3):
+		assert.Fail(t, "discovery hasn't finished after cancel")
+	}
+}
+
+func TestTarget_Template(t *testing.T) {
+	d, err := NewDiscoverer(Config{Files: []string{"*.yaml"}, Tags: "cmdb"})
+	require.NoError(t, err)
+
+	tgg, err := d.parseFile("hosts.yaml", []byte("- name: pg1\n  address: 10.0.0.1:5432\n  tags: postgres\n"))
+	require.NoError(t, err)
+	require.Len(t, tgg.Targets(), 1)
+
+	tmpl := template.Must(template.New("").Parse(`{{.name}} {{.address}} {{.Tags}}`))
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, tgg.Targets()[0]))
+	assert.Equal(t, "pg1 10.0.0.1:5432 {cmdb, postgres}", buf.String())
+
+	_, err = d.parseFile("hosts.yaml", []byte("- __hash__: 1\n"))
+	assert.Error(t, err, "reserved key")
+}
+
+func receive(t *testing.T, in chan []model.TargetGroup) []model.TargetGroup {
+	select {
+	case tggs := <-in:
+		return tggs
+	case <-time.After(time.Second * 3):
+		t.Log("discovery timed out")
+		return nil
+	}
+}
+
+func newTargetGroup(path string, tgts ...model.Target) *targetGroup {
+	return &targetGroup{source: path, targets: tgts}
+}
+
+func newTarget(t *testing.T, path string, idx int, fields map[string]any, tags string) target {
+	item := make(map[string]any, len(fields)+1)
+	for k, v := range fields {
+		item[k] = v
+	}
+	if tags != "" {
+		item[tagsKey] = tags
+	}
+	hash, err := calcHash(item)
+	require.NoError(t, err)
+
+	tgt := target(fields)
+	tgt[metaHashKey] = hash
+	tgt[metaTUIDKey] = fmt.Sprintf("%s_%d_%d", filepath.Base(path), idx, hash)
+	for _, line := range []string{"cmdb", tags} {
+		tt, err := model.ParseTags(line)
+		require.NoError(t, err)
+		tgt.Tags().Merge(tt)
+	}
+	return tgt
+}
@@ -2,6 +2,16 @@
 
 package hostsocket
 
+import "github.com/netdata/go.d.plugin/pkg/web"
+
 type NetworkSocketConfig struct {
-	Tags string
+	Tags     string       `yaml:"tags"`
+	ProcRoot string       `yaml:"proc_root"`
+	Interval web.Duration `yaml:"interval"`
+}
+
+type UnixSocketConfig struct {
+	Tags     string       `yaml:"tags"`
+	ProcRoot string       `yaml:"proc_root"`
+	Interval web.Duration `yaml:"interval"`
 }
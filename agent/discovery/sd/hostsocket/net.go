@@ -4,14 +4,17 @@ package hostsocket
 
 import (
 	"bufio"
-	"bytes"
 	"context"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,6 +24,16 @@ import (
 	"github.com/ilyam8/hashstructure"
 )
 
+const (
+	defaultProcRoot = "/proc"
+	defaultInterval = time.Second * 60
+)
+
+const (
+	tcpStateListen = "0A"
+	tcpStateClose  = "07" // unconnected UDP sockets
+)
+
 type netSocketTargetGroup struct {
 	provider string
 	source   string
@@ -55,41 +68,34 @@ func NewNetSocketDiscoverer(cfg NetworkSocketConfig) (*NetDiscoverer, error) {
 		return nil, fmt.Errorf("parse tags: %v", err)
 	}
 
-	dir := os.Getenv("NETDATA_PLUGINS_DIR")
-	if dir == "" {
-		dir, _ = os.Getwd()
-	}
-
 	d := &NetDiscoverer{
 		Logger: logger.New().With(
 			slog.String("component", "discovery sd hostsocket"),
 		),
-		interval: time.Second * 60,
-		ll: &localListenersExec{
-			binPath: filepath.Join(dir, "local-listeners"),
-			timeout: time.Second * 5,
-		},
+		interval: defaultInterval,
+		procRoot: defaultProcRoot,
+	}
+	if cfg.Interval.Duration > 0 {
+		d.interval = cfg.Interval.Duration
+	}
+	if cfg.ProcRoot != "" {
+		d.procRoot = cfg.ProcRoot
 	}
 	d.Tags().Merge(tags)
 
 	return d, nil
 }
 
-type (
-	NetDiscoverer struct {
-		*logger.Logger
-		model.Base
+type NetDiscoverer struct {
+	*logger.Logger
+	model.Base
 
-		interval time.Duration
-		ll       localListeners
-	}
-	localListeners interface {
-		discover(ctx context.Context) ([]byte, error)
-	}
-)
+	interval time.Duration
+	procRoot string
+}
 
 func (d *NetDiscoverer) Discover(ctx context.Context, in chan<- []model.TargetGroup) {
-	if err := d.discoverLocalListeners(ctx, in); err != nil {
+	if err := d.discoverNetSockets(ctx, in); err != nil {
 		d.Error(err)
 		return
 	}
@@ -102,7 +108,7 @@ func (d *NetDiscoverer) Discover(ctx context.Context, in chan<- []model.TargetGr
 		case <-ctx.Done():
 			return
 		case <-tk.C:
-			if err := d.discoverLocalListeners(ctx, in); err != nil {
+			if err := d.discoverNetSockets(ctx, in); err != nil {
 				d.Error(err)
 				return
 			}
@@ -110,16 +116,8 @@ func (d *NetDiscoverer) Discover(ctx context.Context, in chan<- []model.TargetGr
 	}
 }
 
-func (d *NetDiscoverer) discoverLocalListeners(ctx context.Context, in chan<- []model.TargetGroup) error {
-	bs, err := d.ll.discover(ctx)
-	if err != nil {
-		if errors.Is(err, context.Canceled) {
-			return nil
-		}
-		return err
-	}
-
-	tggs, err := d.parseLocalListeners(bs)
+func (d *NetDiscoverer) discoverNetSockets(ctx context.Context, in chan<- []model.TargetGroup) error {
+	tggs, err := d.readNetSockets()
 	if err != nil {
 		return err
 	}
@@ -131,28 +129,60 @@ func (d *NetDiscoverer) discoverLocalListeners(ctx context.Context, in chan<- []
 	return nil
 }
 
-func (d *NetDiscoverer) parseLocalListeners(bs []byte) ([]model.TargetGroup, error) {
-	var tgts []model.Target
+type netSocket struct {
+	protocol string
+	address  string
+	port     string
+	inode    string
+}
 
-	sc := bufio.NewScanner(bytes.NewReader(bs))
-	for sc.Scan() {
-		text := strings.TrimSpace(sc.Text())
-		if text == "" {
-			continue
+func (d *NetDiscoverer) readNetSockets() ([]model.TargetGroup, error) {
+	var sockets []netSocket
+	var found bool
+
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		ss, err := d.readNetFile(proto)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// no IPv6 support
+				continue
+			}
+			return nil, err
 		}
+		found = true
+		sockets = append(sockets, ss...)
+	}
+	if !found {
+		return nil, fmt.Errorf("no net files found in '%s'", filepath.Join(d.procRoot, "net"))
+	}
 
-		// Protocol|Address|Port|Cmdline
-		parts := strings.SplitN(text, "|", 4)
-		if len(parts) != 4 {
-			return nil, fmt.Errorf("unexpected data: '%s'", text)
+	inodes := make(map[string]bool)
+	for _, s := range sockets {
+		inodes[s.inode] = true
+	}
+	owners := socketOwners(d.procRoot, inodes)
+
+	var tgts []model.Target
+	seen := make(map[string]bool)
+
+	for _, s := range sockets {
+		// the same address can be bound several times (e.g. SO_REUSEPORT)
+		key := s.protocol + "|" + s.address + "|" + s.port
+		if seen[key] {
+			continue
 		}
+		seen[key] = true
 
+		owner := owners[s.inode]
 		tgt := NetSocketTarget{
-			Protocol: parts[0],
-			Address:  parts[1],
-			Port:     parts[2],
-			Comm:     extractComm(parts[3]),
-			Cmdline:  parts[3],
+			Protocol: s.protocol,
+			Address:  s.address,
+			Port:     s.port,
+			Comm:     extractComm(owner.cmdline),
+			Cmdline:  owner.cmdline,
+		}
+		if tgt.Comm == "" {
+			tgt.Comm = owner.comm
 		}
 
 		hash, err := calcHash(tgt)
@@ -175,23 +205,89 @@ func (d *NetDiscoverer) parseLocalListeners(bs []byte) ([]model.TargetGroup, err
 	return []model.TargetGroup{tgg}, nil
 }
 
-type localListenersExec struct {
-	binPath string
-	timeout time.Duration
+func (d *NetDiscoverer) readNetFile(proto string) ([]netSocket, error) {
+	f, err := os.Open(filepath.Join(d.procRoot, "net", proto))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	sockets, err := parseNetFile(f, proto)
+	if err != nil {
+		return nil, fmt.Errorf("parse '%s': %v", f.Name(), err)
+	}
+	return sockets, nil
 }
 
-func (e *localListenersExec) discover(ctx context.Context) ([]byte, error) {
-	execCtx, cancel := context.WithTimeout(ctx, e.timeout)
-	defer cancel()
+// parseNetFile returns the listening sockets from the '/proc/net/{tcp,tcp6,udp,udp6}' file:
+// TCP sockets in the LISTEN state and unconnected UDP sockets.
+func parseNetFile(r io.Reader, proto string) ([]netSocket, error) {
+	var sockets []netSocket
+	isUDP := strings.HasPrefix(proto, "udp")
+
+	sc := bufio.NewScanner(r)
+	for header := true; sc.Scan(); header = false {
+		if header {
+			continue
+		}
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...
+		parts := strings.Fields(text)
+		if len(parts) < 10 {
+			return nil, fmt.Errorf("unexpected data: '%s'", text)
+		}
+
+		state := parts[3]
+		if isUDP {
+			if state != tcpStateClose || !strings.HasSuffix(parts[2], ":0000") {
+				continue
+			}
+		} else if state != tcpStateListen {
+			continue
+		}
+
+		addr, port, err := parseNetAddress(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected data: '%s': %v", text, err)
+		}
+
+		sockets = append(sockets, netSocket{
+			protocol: strings.ToUpper(proto),
+			address:  addr,
+			port:     port,
+			inode:    parts[9],
+		})
+	}
+
+	return sockets, sc.Err()
+}
 
-	cmd := exec.CommandContext(execCtx, e.binPath, "tcp") // TODO: tcp6?
+// parseNetAddress parses the hex encoded 'address:port' pair. The kernel prints the network byte order address
+// as a sequence of 32-bit host byte order words, so every word is converted back.
+func parseNetAddress(s string) (string, string, error) {
+	hexAddr, hexPort, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", "", fmt.Errorf("invalid address '%s'", s)
+	}
 
-	bs, err := cmd.Output()
+	port, err := strconv.ParseUint(hexPort, 16, 16)
 	if err != nil {
-		return nil, fmt.Errorf("error on '%s': %v", cmd, err)
+		return "", "", fmt.Errorf("invalid port '%s'", hexPort)
+	}
+
+	bs, err := hex.DecodeString(hexAddr)
+	if err != nil || (len(bs) != net.IPv4len && len(bs) != net.IPv6len) {
+		return "", "", fmt.Errorf("invalid address '%s'", hexAddr)
+	}
+	for i := 0; i < len(bs); i += 4 {
+		binary.NativeEndian.PutUint32(bs[i:], binary.BigEndian.Uint32(bs[i:]))
 	}
 
-	return bs, nil
+	return net.IP(bs).String(), strconv.FormatUint(port, 10), nil
 }
 
 func extractComm(s string) string {
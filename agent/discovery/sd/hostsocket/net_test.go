@@ -3,34 +3,50 @@
 package hostsocket
 
 import (
-	"context"
-	"errors"
 	"testing"
 
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+
+	"github.com/stretchr/testify/assert"
 )
 
-var (
-	localListenersOutputSample = []byte(`
-UDP6|::1|8125|/opt/netdata/usr/sbin/netdata -P /run/netdata/netdata.pid -D
-TCP6|::1|8125|/opt/netdata/usr/sbin/netdata -P /run/netdata/netdata.pid -D
-TCP|127.0.0.1|8125|/opt/netdata/usr/sbin/netdata -P /run/netdata/netdata.pid -D
-UDP|127.0.0.1|53768|/opt/netdata/usr/libexec/netdata/plugins.d/go.d.plugin 1
-`)
+const (
+	procNetHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+	procNetTCPSample = procNetHeader +
+		"   0: 0100007F:1FBD 00000000:0000 0A 00000000:00000000 00:00000000 00000000   201        0 2001 1 0000000000000000 100 0 0 10 0\n" +
+		"   1: 0100007F:1FBD 00000000:0000 0A 00000000:00000000 00:00000000 00000000   201        0 2005 1 0000000000000000 100 0 0 10 0\n" +
+		"   2: 0100007F:1FBD 0100007F:D2F0 01 00000000:00000000 00:00000000 00000000   201        0 2006 1 0000000000000000 20 4 30 10 -1\n"
+	procNetTCP6Sample = procNetHeader +
+		"   0: 00000000000000000000000001000000:1FBD 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000   201        0 2002 1 0000000000000000 100 0 0 10 0\n"
+	procNetUDPSample = procNetHeader +
+		"  100: 0100007F:D1F8 00000000:0000 07 00000000:00000000 00:00000000 00000000   201        0 2004 2 0000000000000000 0\n" +
+		"  101: 0100007F:D1F9 0100007F:1FBD 01 00000000:00000000 00:00000000 00000000   201        0 2007 2 0000000000000000 0\n"
+	procNetUDP6Sample = procNetHeader +
+		"  200: 00000000000000000000000001000000:1FBD 00000000000000000000000000000000:0000 07 00000000:00000000 00:00000000 00000000   201        0 2003 2 0000000000000000 0\n"
 )
 
 func TestNetSocketDiscoverer_Discover(t *testing.T) {
 	tests := map[string]discoverySim{
-		"valid response": {
-			mock:                 &mockLocalListenersExec{},
+		"valid procfs": {
+			prepare: func(t *testing.T, root string) {
+				writeProcFile(t, root, "net/tcp", procNetTCPSample)
+				writeProcFile(t, root, "net/tcp6", procNetTCP6Sample)
+				writeProcFile(t, root, "net/udp", procNetUDPSample)
+				writeProcFile(t, root, "net/udp6", procNetUDP6Sample)
+				writeProcess(t, root, "10", "netdata",
+					"/opt/netdata/usr/sbin/netdata\x00-P\x00/run/netdata/netdata.pid\x00-D\x00", "2001", "2002", "2003", "2005")
+				writeProcess(t, root, "20", "go.d.plugin",
+					"/opt/netdata/usr/libexec/netdata/plugins.d/go.d.plugin\x001\x00", "2004")
+			},
 			wantDoneBeforeCancel: false,
 			wantTargetGroups: []model.TargetGroup{&netSocketTargetGroup{
 				provider: "hostsocket",
 				source:   "net",
 				targets: []model.Target{
 					withHash(&NetSocketTarget{
-						Protocol: "UDP6",
-						Address:  "::1",
+						Protocol: "TCP",
+						Address:  "127.0.0.1",
 						Port:     "8125",
 						Comm:     "netdata",
 						Cmdline:  "/opt/netdata/usr/sbin/netdata -P /run/netdata/netdata.pid -D",
@@ -43,37 +59,58 @@ func TestNetSocketDiscoverer_Discover(t *testing.T) {
 						Cmdline:  "/opt/netdata/usr/sbin/netdata -P /run/netdata/netdata.pid -D",
 					}),
 					withHash(&NetSocketTarget{
-						Protocol: "TCP",
+						Protocol: "UDP",
 						Address:  "127.0.0.1",
+						Port:     "53752",
+						Comm:     "go.d.plugin",
+						Cmdline:  "/opt/netdata/usr/libexec/netdata/plugins.d/go.d.plugin 1",
+					}),
+					withHash(&NetSocketTarget{
+						Protocol: "UDP6",
+						Address:  "::1",
 						Port:     "8125",
 						Comm:     "netdata",
 						Cmdline:  "/opt/netdata/usr/sbin/netdata -P /run/netdata/netdata.pid -D",
 					}),
+				},
+			}},
+		},
+		"socket without visible owner": {
+			prepare: func(t *testing.T, root string) {
+				writeProcFile(t, root, "net/tcp", procNetTCPSample)
+			},
+			wantDoneBeforeCancel: false,
+			wantTargetGroups: []model.TargetGroup{&netSocketTargetGroup{
+				provider: "hostsocket",
+				source:   "net",
+				targets: []model.Target{
 					withHash(&NetSocketTarget{
-						Protocol: "UDP",
+						Protocol: "TCP",
 						Address:  "127.0.0.1",
-						Port:     "53768",
-						Comm:     "go.d.plugin",
-						Cmdline:  "/opt/netdata/usr/libexec/netdata/plugins.d/go.d.plugin 1",
+						Port:     "8125",
 					}),
 				},
 			}},
 		},
-		"empty response": {
-			mock:                 &mockLocalListenersExec{emptyResponse: true},
+		"no listening sockets": {
+			prepare: func(t *testing.T, root string) {
+				writeProcFile(t, root, "net/tcp", procNetHeader)
+			},
 			wantDoneBeforeCancel: false,
 			wantTargetGroups: []model.TargetGroup{&netSocketTargetGroup{
 				provider: "hostsocket",
 				source:   "net",
 			}},
 		},
-		"error on exec": {
-			mock:                 &mockLocalListenersExec{err: true},
+		"no net files": {
+			prepare:              func(t *testing.T, root string) {},
 			wantDoneBeforeCancel: true,
 			wantTargetGroups:     nil,
 		},
 		"invalid data": {
-			mock:                 &mockLocalListenersExec{invalidResponse: true},
+			prepare: func(t *testing.T, root string) {
+				writeProcFile(t, root, "net/tcp", procNetHeader+"this is very incorrect data\n")
+			},
 			wantDoneBeforeCancel: true,
 			wantTargetGroups:     nil,
 		},
@@ -86,28 +123,19 @@ func TestNetSocketDiscoverer_Discover(t *testing.T) {
 	}
 }
 
+func TestNewNetSocketDiscoverer(t *testing.T) {
+	d, err := NewNetSocketDiscoverer(NetworkSocketConfig{Tags: "hostsocket net"})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultProcRoot, d.procRoot)
+	assert.Equal(t, defaultInterval, d.interval)
+
+	_, err = NewNetSocketDiscoverer(NetworkSocketConfig{Tags: "hostsocket $"})
+	assert.Error(t, err)
+}
+
 func withHash(l *NetSocketTarget) *NetSocketTarget {
 	l.hash, _ = calcHash(l)
 	tags, _ := model.ParseTags("hostsocket net")
 	l.Tags().Merge(tags)
 	return l
 }
-
-type mockLocalListenersExec struct {
-	err             bool
-	emptyResponse   bool
-	invalidResponse bool
-}
-
-func (m *mockLocalListenersExec) discover(context.Context) ([]byte, error) {
-	if m.err {
-		return nil, errors.New("mock discover() error")
-	}
-	if m.emptyResponse {
-		return nil, nil
-	}
-	if m.invalidResponse {
-		return []byte("this is very incorrect data"), nil
-	}
-	return localListenersOutputSample, nil
-}
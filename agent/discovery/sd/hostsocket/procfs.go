@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package hostsocket
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type procInfo struct {
+	comm    string
+	cmdline string
+}
+
+// socketOwners maps socket inodes to the processes holding them open, it walks the '<procRoot>/<pid>/fd' dirs.
+// Processes the plugin has no permission to inspect are skipped.
+func socketOwners(procRoot string, inodes map[string]bool) map[string]procInfo {
+	owners := make(map[string]procInfo)
+	if len(inodes) == 0 {
+		return owners
+	}
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return owners
+	}
+
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		pidDir := filepath.Join(procRoot, entry.Name())
+
+		fds, err := os.ReadDir(filepath.Join(pidDir, "fd"))
+		if err != nil {
+			continue
+		}
+
+		var proc *procInfo
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(pidDir, "fd", fd.Name()))
+			if err != nil {
+				continue
+			}
+			// socket:[12345]
+			inode, ok := strings.CutPrefix(link, "socket:[")
+			if !ok {
+				continue
+			}
+			inode = strings.TrimSuffix(inode, "]")
+			if !inodes[inode] {
+				continue
+			}
+			if _, ok := owners[inode]; ok {
+				continue
+			}
+			if proc == nil {
+				proc = readProcInfo(pidDir)
+			}
+			owners[inode] = *proc
+		}
+	}
+
+	return owners
+}
+
+func readProcInfo(pidDir string) *procInfo {
+	var proc procInfo
+
+	if bs, err := os.ReadFile(filepath.Join(pidDir, "comm")); err == nil {
+		proc.comm = strings.TrimSpace(string(bs))
+	}
+	if bs, err := os.ReadFile(filepath.Join(pidDir, "cmdline")); err == nil {
+		bs = bytes.TrimRight(bs, "\x00")
+		proc.cmdline = string(bytes.ReplaceAll(bs, []byte{0}, []byte{' '}))
+	}
+
+	return &proc
+}
@@ -4,6 +4,9 @@ package hostsocket
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
@@ -14,16 +17,17 @@ import (
 )
 
 type discoverySim struct {
-	mock                 *mockLocalListenersExec
+	prepare              func(t *testing.T, root string)
 	wantDoneBeforeCancel bool
 	wantTargetGroups     []model.TargetGroup
 }
 
 func (sim *discoverySim) run(t *testing.T) {
-	d, err := NewNetSocketDiscoverer(NetworkSocketConfig{Tags: "hostsocket net"})
-	require.NoError(t, err)
+	root := t.TempDir()
+	sim.prepare(t, root)
 
-	d.ll = sim.mock
+	d, err := NewNetSocketDiscoverer(NetworkSocketConfig{Tags: "hostsocket net", ProcRoot: root})
+	require.NoError(t, err)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	tggs, done := sim.collectTargetGroups(t, ctx, d)
@@ -73,3 +77,21 @@ func (sim *discoverySim) collectTargetGroups(t *testing.T, ctx context.Context,
 
 	return tggs, done
 }
+
+func writeProcFile(t *testing.T, root, name, content string) {
+	path := filepath.Join(root, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func writeProcess(t *testing.T, root, pid, comm, cmdline string, socketInodes ...string) {
+	writeProcFile(t, root, filepath.Join(pid, "comm"), comm+"\n")
+	writeProcFile(t, root, filepath.Join(pid, "cmdline"), cmdline)
+
+	fdDir := filepath.Join(root, pid, "fd")
+	require.NoError(t, os.MkdirAll(fdDir, 0755))
+	require.NoError(t, os.Symlink("/dev/null", filepath.Join(fdDir, "0")))
+	for i, inode := range socketInodes {
+		require.NoError(t, os.Symlink("socket:["+inode+"]", filepath.Join(fdDir, strconv.Itoa(3+i))))
+	}
+}
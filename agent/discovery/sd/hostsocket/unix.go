@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package hostsocket
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/logger"
+)
+
+// __SO_ACCEPTCON, set for the listening sockets
+const unixSocketAcceptCon = 0x10000
+
+type unixSocketTargetGroup struct {
+	provider string
+	source   string
+	targets  []model.Target
+}
+
+func (g *unixSocketTargetGroup) Provider() string        { return g.provider }
+func (g *unixSocketTargetGroup) Source() string          { return g.source }
+func (g *unixSocketTargetGroup) Targets() []model.Target { return g.targets }
+
+type UnixSocketTarget struct {
+	model.Base
+
+	hash uint64
+
+	Path    string
+	Comm    string
+	Cmdline string
+}
+
+func (t *UnixSocketTarget) TUID() string { return t.tuid() }
+func (t *UnixSocketTarget) Hash() uint64 { return t.hash }
+func (t *UnixSocketTarget) tuid() string {
+	return fmt.Sprintf("unix_%s_%d", t.Path, t.hash)
+}
+
+func NewUnixSocketDiscoverer(cfg UnixSocketConfig) (*UnixDiscoverer, error) {
+	tags, err := model.ParseTags(cfg.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("parse tags: %v", err)
+	}
+
+	d := &UnixDiscoverer{
+		Logger: logger.New().With(
+			slog.String("component", "discovery sd hostsocket"),
+		),
+		interval: defaultInterval,
+		procRoot: defaultProcRoot,
+	}
+	if cfg.Interval.Duration > 0 {
+		d.interval = cfg.Interval.Duration
+	}
+	if cfg.ProcRoot != "" {
+		d.procRoot = cfg.ProcRoot
+	}
+	d.Tags().Merge(tags)
+
+	return d, nil
+}
+
+type UnixDiscoverer struct {
+	*logger.Logger
+	model.Base
+
+	interval time.Duration
+	procRoot string
+}
+
+func (d *UnixDiscoverer) Discover(ctx context.Context, in chan<- []model.TargetGroup) {
+	if err := d.discoverUnixSockets(ctx, in); err != nil {
+		d.Error(err)
+		return
+	}
+
+	tk := time.NewTicker(d.interval)
+	defer tk.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			if err := d.discoverUnixSockets(ctx, in); err != nil {
+				d.Error(err)
+				return
+			}
+		}
+	}
+}
+
+func (d *UnixDiscoverer) discoverUnixSockets(ctx context.Context, in chan<- []model.TargetGroup) error {
+	f, err := os.Open(filepath.Join(d.procRoot, "net", "unix"))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	tggs, err := d.parseUnixSockets(f)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+	case in <- tggs:
+	}
+	return nil
+}
+
+func (d *UnixDiscoverer) parseUnixSockets(r io.Reader) ([]model.TargetGroup, error) {
+	type socket struct{ inode, path string }
+	var sockets []socket
+	inodes := make(map[string]bool)
+	seen := make(map[string]bool)
+
+	sc := bufio.NewScanner(r)
+	for header := true; sc.Scan(); header = false {
+		if header {
+			continue
+		}
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+
+		// Num RefCount Protocol Flags Type St Inode Path
+		parts := strings.Fields(text)
+		if len(parts) < 7 {
+			return nil, fmt.Errorf("unexpected data: '%s'", text)
+		}
+		if len(parts) == 7 {
+			// unnamed socket
+			continue
+		}
+
+		flags, err := strconv.ParseUint(parts[3], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected data: '%s'", text)
+		}
+		path := strings.Join(parts[7:], " ")
+
+		// only the listening sockets bound to a filesystem path, abstract sockets start with '@'
+		if flags&unixSocketAcceptCon == 0 || !strings.HasPrefix(path, "/") || seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		inodes[parts[6]] = true
+		sockets = append(sockets, socket{inode: parts[6], path: path})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	owners := socketOwners(d.procRoot, inodes)
+
+	var tgts []model.Target
+	for _, s := range sockets {
+		tgt := UnixSocketTarget{
+			Path:    s.path,
+			Comm:    owners[s.inode].comm,
+			Cmdline: owners[s.inode].cmdline,
+		}
+
+		hash, err := calcHash(tgt)
+		if err != nil {
+			continue
+		}
+
+		tgt.hash = hash
+		tgt.Tags().Merge(d.Tags())
+
+		tgts = append(tgts, &tgt)
+	}
+
+	tgg := &unixSocketTargetGroup{
+		provider: "hostsocket",
+		source:   "unix",
+		targets:  tgts,
+	}
+
+	return []model.TargetGroup{tgg}, nil
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package hostsocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const procNetUnixSample = `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 1001 /run/php/php-fpm.sock
+0000000000000000: 00000002 00000000 00010000 0001 01 1002 /var/run/mysqld/mysqld.sock
+0000000000000000: 00000003 00000000 00000000 0001 03 1003 /var/run/mysqld/mysqld.sock
+0000000000000000: 00000002 00000000 00010000 0001 01 1004 @/tmp/.X11-unix/X0
+0000000000000000: 00000002 00000000 00000000 0002 01 1005 /run/systemd/journal/dev-log
+0000000000000000: 00000003 00000000 00000000 0001 03 1006
+0000000000000000: 00000002 00000000 00010000 0001 01 1007 /run/docker.sock
+`
+
+func TestUnixSocketDiscoverer_Discover(t *testing.T) {
+	tests := map[string]struct {
+		prepare              func(t *testing.T, root string)
+		wantDoneBeforeCancel bool
+		wantTargetGroups     []model.TargetGroup
+	}{
+		"valid procfs": {
+			prepare: func(t *testing.T, root string) {
+				writeProcFile(t, root, "net/unix", procNetUnixSample)
+				writeProcess(t, root, "100", "php-fpm8.2", "php-fpm: master process (/etc/php/8.2/fpm/php-fpm.conf)", "1001")
+				writeProcess(t, root, "200", "mysqld", "/usr/sbin/mysqld\x00--basedir=/usr\x00", "1002", "1003")
+			},
+			wantTargetGroups: []model.TargetGroup{&unixSocketTargetGroup{
+				provider: "hostsocket",
+				source:   "unix",
+				targets: []model.Target{
+					withUnixHash(&UnixSocketTarget{
+						Path:    "/run/php/php-fpm.sock",
+						Comm:    "php-fpm8.2",
+						Cmdline: "php-fpm: master process (/etc/php/8.2/fpm/php-fpm.conf)",
+					}),
+					withUnixHash(&UnixSocketTarget{
+						Path:    "/var/run/mysqld/mysqld.sock",
+						Comm:    "mysqld",
+						Cmdline: "/usr/sbin/mysqld --basedir=/usr",
+					}),
+					withUnixHash(&UnixSocketTarget{
+						Path: "/run/docker.sock",
+					}),
+				},
+			}},
+		},
+		"no listening sockets": {
+			prepare: func(t *testing.T, root string) {
+				writeProcFile(t, root, "net/unix", "Num       RefCount Protocol Flags    Type St Inode Path\n")
+			},
+			wantTargetGroups: []model.TargetGroup{&unixSocketTargetGroup{
+				provider: "hostsocket",
+				source:   "unix",
+			}},
+		},
+		"no net/unix file": {
+			prepare:              func(t *testing.T, root string) {},
+			wantDoneBeforeCancel: true,
+		},
+		"invalid data": {
+			prepare: func(t *testing.T, root string) {
+				writeProcFile(t, root, "net/unix", "header\nthis is very incorrect data\n")
+			},
+			wantDoneBeforeCancel: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			test.prepare(t, root)
+
+			d, err := NewUnixSocketDiscoverer(UnixSocketConfig{Tags: "hostsocket unix"})
+			require.NoError(t, err)
+			d.procRoot = root
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			in := make(chan []model.TargetGroup)
+			done := make(chan struct{})
+			go func() { defer close(done); d.Discover(ctx, in) }()
+
+			var tggs []model.TargetGroup
+			select {
+			case tggs = <-in:
+			case <-done:
+			case <-time.After(time.Second * 3):
+				t.Log("discovery timed out")
+			}
+
+			if test.wantDoneBeforeCancel {
+				select {
+				case <-done:
+				default:
+					assert.Fail(t, "discovery hasn't finished before cancel")
+				}
+			}
+			assert.Equal(t, test.wantTargetGroups, tggs)
+
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(time.Second * 3):
+				assert.Fail(t, "discovery hasn't finished after cancel")
+			}
+		})
+	}
+}
+
+func withUnixHash(tgt *UnixSocketTarget) *UnixSocketTarget {
+	tgt.hash, _ = calcHash(tgt)
+	tags, _ := model.ParseTags("hostsocket unix")
+	tgt.Tags().Merge(tags)
+	return tgt
+}
@@ -5,8 +5,10 @@ package pipeline
 import (
 	"errors"
 	"fmt"
-	"github.com/netdata/go.d.plugin/agent/discovery/sd/hostsocket"
 
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/docker"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/file"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/hostsocket"
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/kubernetes"
 )
 
@@ -21,9 +23,12 @@ type (
 	DiscoveryConfig struct {
 		K8s        []kubernetes.Config `yaml:"k8s"`
 		HostSocket HostSocketConfig    `yaml:"hostsocket"`
+		Docker     []docker.Config     `yaml:"docker"`
+		File       []file.Config       `yaml:"file"`
 	}
 	HostSocketConfig struct {
-		Net *hostsocket.NetworkSocketConfig `yaml:"net"`
+		Net  *hostsocket.NetworkSocketConfig `yaml:"net"`
+		Unix *hostsocket.UnixSocketConfig    `yaml:"unix"`
 	}
 )
 
@@ -47,11 +52,12 @@ type ComposeRuleConfig struct {
 }
 
 func validateConfig(cfg Config) error {
-	if cfg.Name != "" {
+	if cfg.Name == "" {
 		return errors.New("'name' not set")
 	}
-	if len(cfg.Discovery.K8s) == 0 {
-		return errors.New("'discovery->k8s' not set")
+	if len(cfg.Discovery.K8s) == 0 && cfg.Discovery.HostSocket.Net == nil && cfg.Discovery.HostSocket.Unix == nil &&
+		len(cfg.Discovery.Docker) == 0 && len(cfg.Discovery.File) == 0 {
+		return errors.New("'discovery' not set, need at least 1 discoverer")
 	}
 	if err := validateClassifyConfig(cfg.Classify); err != nil {
 		return fmt.Errorf("tag rules: %v", err)
@@ -4,10 +4,13 @@ package pipeline
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/docker"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/file"
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/hostsocket"
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/kubernetes"
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
@@ -19,14 +22,30 @@ func New(cfg Config) (*Pipeline, error) {
 		return nil, err
 	}
 
+	clr, err := newTargetClassificator(cfg.Classify)
+	if err != nil {
+		return nil, fmt.Errorf("classify rules: %v", err)
+	}
+
+	cmr, err := newConfigComposer(cfg.Compose)
+	if err != nil {
+		return nil, fmt.Errorf("compose rules: %v", err)
+	}
+
 	p := &Pipeline{
 		Logger: logger.New().With(
 			slog.String("component", "discovery sd pipeline"),
+			slog.String("pipeline", cfg.Name),
 		),
 		accum:       newAccumulator(),
 		discoverers: make([]model.Discoverer, 0),
+		clr:         clr,
+		cmr:         cmr,
 		items:       make(map[string]map[uint64][]confgroup.Config),
 	}
+	p.accum.Logger = p.Logger
+	clr.Logger = p.Logger
+	cmr.Logger = p.Logger
 
 	if err := p.registerDiscoverers(cfg); err != nil {
 		return nil, err
@@ -70,6 +89,27 @@ func (p *Pipeline) registerDiscoverers(conf Config) error {
 		}
 		p.discoverers = append(p.discoverers, td)
 	}
+	if conf.Discovery.HostSocket.Unix != nil {
+		td, err := hostsocket.NewUnixSocketDiscoverer(*conf.Discovery.HostSocket.Unix)
+		if err != nil {
+			return err
+		}
+		p.discoverers = append(p.discoverers, td)
+	}
+	for _, cfg := range conf.Discovery.Docker {
+		td, err := docker.NewDiscoverer(cfg)
+		if err != nil {
+			return err
+		}
+		p.discoverers = append(p.discoverers, td)
+	}
+	for _, cfg := range conf.Discovery.File {
+		td, err := file.NewDiscoverer(cfg)
+		if err != nil {
+			return err
+		}
+		p.discoverers = append(p.discoverers, td)
+	}
 
 	return nil
 }
@@ -27,6 +27,126 @@ func TestNew(t *testing.T) {
 			wantErr: true,
 			config:  "",
 		},
+		"fails when name not set": {
+			wantErr: true,
+			config: `
+discovery:
+  hostsocket:
+    net:
+      tags: "netsocket"
+classify:
+  - selector: "netsocket"
+    tags: "apps"
+    match:
+      - tags: "app"
+        expr: '{{ eq .Port "80" }}'
+compose:
+  - selector: "apps"
+    config:
+      - selector: "app"
+        template: "module: app"
+`,
+		},
+		"fails when discoverers not set": {
+			wantErr: true,
+			config: `
+name: test
+classify:
+  - selector: "netsocket"
+    tags: "apps"
+    match:
+      - tags: "app"
+        expr: '{{ eq .Port "80" }}'
+compose:
+  - selector: "apps"
+    config:
+      - selector: "app"
+        template: "module: app"
+`,
+		},
+		"docker discoverer": {
+			wantErr: false,
+			config: `
+name: test
+discovery:
+  docker:
+    - address: "unix:///var/run/docker.sock"
+      tags: "container"
+classify:
+  - selector: "container"
+    tags: "apps"
+    match:
+      - tags: "app"
+        expr: '{{ eq .PrivatePort "80" }}'
+compose:
+  - selector: "apps"
+    config:
+      - selector: "app"
+        template: "module: app"
+`,
+		},
+		"file discoverer": {
+			wantErr: false,
+			config: `
+name: test
+discovery:
+  file:
+    - files: ["/etc/netdata/cmdb/*.yaml"]
+      tags: "cmdb"
+classify:
+  - selector: "cmdb"
+    tags: "apps"
+    match:
+      - tags: "app"
+        expr: '{{ eq .kind "postgres" }}'
+compose:
+  - selector: "apps"
+    config:
+      - selector: "app"
+        template: "module: app"
+`,
+		},
+		"fails when file discoverer files not set": {
+			wantErr: true,
+			config: `
+name: test
+discovery:
+  file:
+    - tags: "cmdb"
+classify:
+  - selector: "cmdb"
+    tags: "apps"
+    match:
+      - tags: "app"
+        expr: '{{ eq .kind "postgres" }}'
+compose:
+  - selector: "apps"
+    config:
+      - selector: "app"
+        template: "module: app"
+`,
+		},
+		"fails when classify expr is invalid": {
+			wantErr: true,
+			config: `
+name: test
+discovery:
+  k8s:
+    - pod:
+        tags: "pod"
+classify:
+  - selector: "pod"
+    tags: "apps"
+    match:
+      - tags: "app"
+        expr: '{{ eq .Port "80" '
+compose:
+  - selector: "apps"
+    config:
+      - selector: "app"
+        template: "module: app"
+`,
+		},
 	}
 
 	for name, test := range tests {
@@ -4,7 +4,12 @@ package sd
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
 	"sync"
+	"time"
 
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/pipeline"
@@ -13,8 +18,43 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-func NewServiceDiscovery() (*ServiceDiscovery, error) {
-	return nil, nil
+type Config struct {
+	// ConfDir is the list of directories with the pipeline config files (one pipeline per file).
+	// A file overrides the files with the same name in the following directories.
+	ConfDir  []string
+	Registry confgroup.Registry
+}
+
+func validateConfig(cfg Config) error {
+	if len(cfg.ConfDir) == 0 {
+		return errors.New("conf dir not set")
+	}
+	if len(cfg.Registry) == 0 {
+		return errors.New("empty config registry")
+	}
+	return nil
+}
+
+func NewServiceDiscovery(cfg Config) (*ServiceDiscovery, error) {
+	if err := validateConfig(cfg); err != nil {
+		return nil, fmt.Errorf("service discovery config validation: %v", err)
+	}
+
+	log := logger.New().With(
+		slog.String("component", "service discovery"),
+	)
+
+	d := &ServiceDiscovery{
+		Logger:       log,
+		confProv:     newConfFileProvider(log, cfg.ConfDir),
+		sdFactory:    pipelineFactory{},
+		reg:          cfg.Registry,
+		confCache:    make(map[string]uint64),
+		pipelines:    make(map[string]*pipelineRun),
+		staleTimeout: time.Second * 30,
+	}
+
+	return d, nil
 }
 
 type (
@@ -23,9 +63,11 @@ type (
 
 		confProv  ConfigFileProvider
 		sdFactory sdPipelineFactory
+		reg       confgroup.Registry
 
-		confCache map[string]uint64
-		pipelines map[string]func()
+		confCache    map[string]uint64
+		pipelines    map[string]*pipelineRun // [config file]pipeline
+		staleTimeout time.Duration
 	}
 	sdPipeline interface {
 		Run(ctx context.Context, in chan<- []*confgroup.Group)
@@ -35,6 +77,20 @@ type (
 	}
 )
 
+type pipelineFactory struct{}
+
+func (pipelineFactory) create(cfg pipeline.Config) (sdPipeline, error) {
+	return pipeline.New(cfg)
+}
+
+func (d *ServiceDiscovery) String() string {
+	return d.Name()
+}
+
+func (d *ServiceDiscovery) Name() string {
+	return "service discovery"
+}
+
 func (d *ServiceDiscovery) Run(ctx context.Context, in chan<- []*confgroup.Group) {
 	d.Info("instance is started")
 	defer d.Info("instance is stopped")
@@ -55,7 +111,7 @@ func (d *ServiceDiscovery) Run(ctx context.Context, in chan<- []*confgroup.Group
 			}
 			if len(cf.Data) == 0 {
 				delete(d.confCache, cf.Source)
-				d.removePipeline(cf)
+				d.removePipeline(ctx, cf, in)
 			} else if hash, ok := d.confCache[cf.Source]; !ok || hash != cf.Hash() {
 				d.confCache[cf.Source] = cf.Hash()
 				d.addPipeline(ctx, cf, in)
@@ -64,6 +120,21 @@ func (d *ServiceDiscovery) Run(ctx context.Context, in chan<- []*confgroup.Group
 	}
 }
 
+// pipelineRun is a running pipeline. The sources are the sources of the sent not empty groups,
+// they are owned by the forward goroutine until the pipeline is stopped.
+type pipelineRun struct {
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	sources map[string]bool
+}
+
+// stop stops the pipeline and returns its sources.
+func (r *pipelineRun) stop() map[string]bool {
+	r.cancel()
+	r.wg.Wait()
+	return r.sources
+}
+
 func (d *ServiceDiscovery) addPipeline(ctx context.Context, cf ConfigFile, in chan<- []*confgroup.Group) {
 	var cfg pipeline.Config
 
@@ -74,33 +145,133 @@ func (d *ServiceDiscovery) addPipeline(ctx context.Context, cf ConfigFile, in ch
 
 	pl, err := d.sdFactory.create(cfg)
 	if err != nil {
-		d.Error(err)
+		d.Errorf("pipeline '%s': %v", cf.Source, err)
 		return
 	}
 
-	if stop, ok := d.pipelines[cf.Source]; ok {
-		stop()
+	// the new pipeline takes over the sources of the replaced one, so the jobs with unchanged configs keep running
+	var stale map[string]bool
+	if prev, ok := d.pipelines[cf.Source]; ok {
+		stale = prev.stop()
 	}
 
-	var wg sync.WaitGroup
+	d.Infof("starting pipeline '%s' (%s)", cfg.Name, cf.Source)
+
 	plCtx, cancel := context.WithCancel(ctx)
+	out := make(chan []*confgroup.Group)
+	run := &pipelineRun{cancel: cancel, sources: make(map[string]bool)}
 
-	wg.Add(1)
-	go func() { defer wg.Done(); pl.Run(plCtx, in) }()
-	stop := func() { cancel(); wg.Wait() }
+	run.wg.Add(1)
+	go func() { defer run.wg.Done(); pl.Run(plCtx, out) }()
+
+	run.wg.Add(1)
+	go func() { defer run.wg.Done(); d.forward(plCtx, cf.Source, out, in, run.sources, stale) }()
 
-	d.pipelines[cf.Source] = stop
+	d.pipelines[cf.Source] = run
 }
 
-func (d *ServiceDiscovery) removePipeline(cf ConfigFile) {
-	if stop, ok := d.pipelines[cf.Source]; ok {
+func (d *ServiceDiscovery) removePipeline(ctx context.Context, cf ConfigFile, in chan<- []*confgroup.Group) {
+	if run, ok := d.pipelines[cf.Source]; ok {
 		delete(d.pipelines, cf.Source)
-		stop()
+		d.removeSources(ctx, in, run.stop())
+	}
+}
+
+// forward applies the module defaults to the pipeline configs and sends them. The group sources are prefixed
+// with the pipeline config file, so the pipelines using the same discoverers don't overwrite each other's groups.
+// It tracks the sent sources to remove them when the pipeline is stopped. The stale sources (of the replaced
+// pipeline) that are not reported by this pipeline within the stale timeout are removed.
+func (d *ServiceDiscovery) forward(ctx context.Context, prefix string, out <-chan []*confgroup.Group, in chan<- []*confgroup.Group, sources, stale map[string]bool) {
+	defer func() {
+		for source := range stale {
+			sources[source] = true
+		}
+	}()
+
+	var staleC <-chan time.Time
+	if len(stale) > 0 {
+		tm := time.NewTimer(d.staleTimeout)
+		defer tm.Stop()
+		staleC = tm.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-staleC:
+			var groups []*confgroup.Group
+			for source := range stale {
+				groups = append(groups, &confgroup.Group{Source: source})
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case in <- groups:
+				stale = nil
+			}
+		case groups := <-out:
+			groups = d.applyDefaults(prefix, groups)
+			for _, gr := range groups {
+				delete(stale, gr.Source)
+				if len(gr.Configs) == 0 {
+					delete(sources, gr.Source)
+				} else {
+					sources[gr.Source] = true
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case in <- groups:
+			}
+		}
+	}
+}
+
+func (d *ServiceDiscovery) applyDefaults(prefix string, groups []*confgroup.Group) []*confgroup.Group {
+	res := make([]*confgroup.Group, 0, len(groups))
+
+	for _, gr := range groups {
+		source := prefix + ":" + gr.Source
+		group := &confgroup.Group{Source: source}
+		for _, cfg := range gr.Configs {
+			def, ok := d.reg.Lookup(cfg.Module())
+			if !ok {
+				d.Debugf("'%s' module is not registered or disabled, skipping '%s' config", cfg.Module(), cfg.Name())
+				continue
+			}
+			// the configs are cached by the pipeline
+			cfg = maps.Clone(cfg)
+			cfg.SetSource(source)
+			cfg.Apply(def)
+			group.Configs = append(group.Configs, cfg)
+		}
+		res = append(res, group)
+	}
+
+	return res
+}
+
+// removeSources sends empty groups for the sources of a stopped pipeline to stop their jobs.
+func (d *ServiceDiscovery) removeSources(ctx context.Context, in chan<- []*confgroup.Group, sources map[string]bool) {
+	if len(sources) == 0 {
+		return
+	}
+
+	var groups []*confgroup.Group
+	for source := range sources {
+		groups = append(groups, &confgroup.Group{Source: source})
+	}
+
+	select {
+	case <-ctx.Done():
+	case in <- groups:
 	}
 }
 
 func (d *ServiceDiscovery) cleanup() {
-	for _, stop := range d.pipelines {
-		stop()
+	for _, run := range d.pipelines {
+		run.stop()
 	}
 }
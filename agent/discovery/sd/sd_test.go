@@ -3,10 +3,17 @@
 package sd
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/pipeline"
+	"github.com/netdata/go.d.plugin/logger"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
 )
 
@@ -87,3 +94,132 @@ func prepareEmptyConfigFile(source string) ConfigFile {
 		Source: source,
 	}
 }
+
+func TestServiceDiscovery_Run_ForwardsPipelineConfigs(t *testing.T) {
+	pl := &sendingPipeline{groups: []*confgroup.Group{
+		{
+			Source: "k8s/pod/default/pod1",
+			Configs: []confgroup.Config{
+				{"module": "module1", "name": "job1"},
+				{"module": "unknown", "name": "job2"},
+			},
+		},
+	}}
+	prov := &mockConfigProvider{ch: make(chan ConfigFile)}
+	d := &ServiceDiscovery{
+		Logger:    logger.New(),
+		sdFactory: &sendingFactory{pl: pl},
+		confProv:  prov,
+		reg:       confgroup.Registry{"module1": confgroup.Default{UpdateEvery: 5}},
+		confCache: make(map[string]uint64),
+		pipelines: make(map[string]*pipelineRun),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan []*confgroup.Group)
+	go d.Run(ctx, in)
+
+	recv := func() []*confgroup.Group {
+		select {
+		case groups := <-in:
+			return groups
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for config groups")
+			return nil
+		}
+	}
+
+	prov.ch <- prepareConfigFile("source", "name")
+
+	groups := recv()
+	require.Len(t, groups, 1)
+	assert.Equal(t, "source:k8s/pod/default/pod1", groups[0].Source)
+	require.Len(t, groups[0].Configs, 1)
+	assert.Equal(t, "module1", groups[0].Configs[0].Module())
+	assert.Equal(t, 5, groups[0].Configs[0].UpdateEvery())
+	assert.Equal(t, "source:k8s/pod/default/pod1", groups[0].Configs[0].Source())
+	assert.Equal(t, 0, pl.groups[0].Configs[0].UpdateEvery(), "the pipeline config is changed")
+
+	// the config file is removed, the pipeline sources are removed too
+	prov.ch <- prepareEmptyConfigFile("source")
+	assert.Equal(t, []*confgroup.Group{{Source: "source:k8s/pod/default/pod1"}}, recv())
+}
+
+func TestServiceDiscovery_Run_ReplacePipeline(t *testing.T) {
+	job := func(name string) confgroup.Config { return confgroup.Config{"module": "module1", "name": name} }
+	fact := &groupsFactory{groups: map[string][]*confgroup.Group{
+		"v1": {
+			{Source: "net", Configs: []confgroup.Config{job("job1")}},
+			{Source: "unix", Configs: []confgroup.Config{job("job2")}},
+		},
+		"v2": {
+			{Source: "net", Configs: []confgroup.Config{job("job1")}},
+		},
+	}}
+	prov := &mockConfigProvider{ch: make(chan ConfigFile)}
+	d := &ServiceDiscovery{
+		Logger:       logger.New(),
+		sdFactory:    fact,
+		confProv:     prov,
+		reg:          confgroup.Registry{"module1": confgroup.Default{}},
+		confCache:    make(map[string]uint64),
+		pipelines:    make(map[string]*pipelineRun),
+		staleTimeout: time.Millisecond * 200,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan []*confgroup.Group)
+	go d.Run(ctx, in)
+
+	recv := func() []*confgroup.Group {
+		select {
+		case groups := <-in:
+			return groups
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for config groups")
+			return nil
+		}
+	}
+	sources := func(groups []*confgroup.Group) (res []string) {
+		for _, gr := range groups {
+			res = append(res, fmt.Sprintf("%s(%d)", gr.Source, len(gr.Configs)))
+		}
+		return res
+	}
+
+	// two pipelines with the same discoverers don't overwrite each other's groups
+	prov.ch <- prepareConfigFile("a.conf", "v1")
+	assert.Equal(t, []string{"a.conf:net(1)", "a.conf:unix(1)"}, sources(recv()))
+	prov.ch <- prepareConfigFile("b.conf", "v1")
+	assert.Equal(t, []string{"b.conf:net(1)", "b.conf:unix(1)"}, sources(recv()))
+
+	// the replaced pipeline sources are not removed, the stale ones are removed after the timeout
+	prov.ch <- prepareConfigFile("a.conf", "v2")
+	assert.Equal(t, []string{"a.conf:net(1)"}, sources(recv()))
+	assert.Equal(t, []string{"a.conf:unix(0)"}, sources(recv()))
+}
+
+type groupsFactory struct{ groups map[string][]*confgroup.Group }
+
+func (f *groupsFactory) create(cfg pipeline.Config) (sdPipeline, error) {
+	return &sendingPipeline{groups: f.groups[cfg.Name]}, nil
+}
+
+type sendingFactory struct{ pl *sendingPipeline }
+
+func (f *sendingFactory) create(pipeline.Config) (sdPipeline, error) { return f.pl, nil }
+
+type sendingPipeline struct{ groups []*confgroup.Group }
+
+func (p *sendingPipeline) Run(ctx context.Context, in chan<- []*confgroup.Group) {
+	select {
+	case <-ctx.Done():
+		return
+	case in <- p.groups:
+	}
+	<-ctx.Done()
+}
@@ -33,7 +33,7 @@ func (sim *discoverySim) run(t *testing.T) {
 			ch:      make(chan ConfigFile),
 		},
 		confCache: make(map[string]uint64),
-		pipelines: make(map[string]func()),
+		pipelines: make(map[string]*pipelineRun),
 	}
 
 	in := make(chan<- []*confgroup.Group)
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/discovery"
+	"github.com/netdata/go.d.plugin/agent/discovery/dummy"
+	"github.com/netdata/go.d.plugin/agent/discovery/file"
+	"github.com/netdata/go.d.plugin/agent/jobmgr"
+)
+
+// dryRunMaxValues is the number of collected values printed for every job.
+const dryRunMaxValues = 3
+
+// DryRun loads the plugin and modules configs, runs one data collection for every job and
+// prints the results as a table. It returns false if there are no jobs or any of them failed.
+func (a *Agent) DryRun(w io.Writer) bool {
+	cfg := a.loadPluginConfig()
+	enabledModules := a.loadEnabledModules(cfg)
+	if len(enabledModules) == 0 {
+		_, _ = fmt.Fprintln(w, "no modules to run")
+		return false
+	}
+
+	groups := a.dryRunConfigGroups(a.buildDiscoveryConf(enabledModules))
+
+	jobsManager := jobmgr.NewManager()
+	jobsManager.PluginName = a.Name
+	jobsManager.Modules = enabledModules
+	if reg := a.setupVnodeRegistry(); reg != nil {
+		jobsManager.Vnodes = reg
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "MODULE\tJOB\tSTATUS\tCHARTS\tVALUES\tSOURCE")
+
+	var total, failed int
+	for _, group := range groups {
+		for _, jobCfg := range group.Configs {
+			total++
+			res := jobsManager.DryRun(jobCfg)
+
+			status, values := "PASS", formatDryRunValues(res.Metrics)
+			if res.Err != nil {
+				failed++
+				status, values = "FAIL", res.Err.Error()
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+				jobCfg.Module(), jobCfg.Name(), status, res.Charts, values, jobCfg.Source())
+		}
+	}
+	_ = tw.Flush()
+
+	_, _ = fmt.Fprintf(w, "\njobs: %d, passed: %d, failed: %d\n", total, total-failed, failed)
+
+	return total > 0 && failed == 0
+}
+
+func (a *Agent) dryRunConfigGroups(discCfg discovery.Config) []*confgroup.Group {
+	var groups []*confgroup.Group
+
+	read := func(run func(ctx context.Context, in chan<- []*confgroup.Group)) {
+		in := make(chan []*confgroup.Group, 1)
+		run(context.Background(), in)
+		for v := range in {
+			groups = append(groups, v...)
+		}
+	}
+
+	// the watched (service discovery) files are read once
+	paths := append(append([]string{}, discCfg.File.Read...), discCfg.File.Watch...)
+	read(file.NewReader(discCfg.Registry, paths).Run)
+
+	if len(discCfg.Dummy.Names) > 0 {
+		if d, err := dummy.NewDiscovery(dummy.Config{Registry: discCfg.Registry, Names: discCfg.Dummy.Names}); err != nil {
+			a.Warning(err)
+		} else {
+			read(d.Run)
+		}
+	}
+
+	var i int
+	for _, group := range groups {
+		if group != nil && len(group.Configs) > 0 {
+			groups[i] = group
+			i++
+		}
+	}
+	return groups[:i]
+}
+
+func formatDryRunValues(metrics map[string]int64) string {
+	keys := make([]string, 0, len(metrics))
+	for k := range metrics {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys[:min(len(keys), dryRunMaxValues)] {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, metrics[k]))
+	}
+	if len(keys) > dryRunMaxValues {
+		parts = append(parts, fmt.Sprintf("(+%d more)", len(keys)-dryRunMaxValues))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_formatDryRunValues(t *testing.T) {
+	tests := map[string]struct {
+		metrics map[string]int64
+		want    string
+	}{
+		"no metrics":    {metrics: nil, want: ""},
+		"less than max": {metrics: map[string]int64{"b": 2, "a": 1}, want: "a=1 b=2"},
+		"more than max": {metrics: map[string]int64{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5}, want: "a=1 b=2 c=3 (+2 more)"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, formatDryRunValues(test.metrics))
+		})
+	}
+}
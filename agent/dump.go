@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/netdata/go.d.plugin/agent/internal/defaults"
+	"github.com/netdata/go.d.plugin/agent/module"
+)
+
+const (
+	dumpFormatJSON     = "json"
+	dumpFormatMarkdown = "markdown"
+)
+
+type (
+	moduleDump struct {
+		Name         string          `json:"name"`
+		Defaults     moduleDefaults  `json:"defaults"`
+		ConfigSchema json.RawMessage `json:"config_schema,omitempty"`
+		Charts       []chartDump     `json:"charts"`
+		ChartsError  string          `json:"charts_error,omitempty"`
+	}
+	moduleDefaults struct {
+		UpdateEvery        int            `json:"update_every"`
+		AutoDetectionRetry int            `json:"autodetection_retry"`
+		Priority           int            `json:"priority"`
+		Disabled           bool           `json:"disabled"`
+		Backoff            module.Backoff `json:"backoff"`
+	}
+	chartDump struct {
+		ID      string    `json:"id"`
+		Title   string    `json:"title"`
+		Units   string    `json:"units"`
+		Family  string    `json:"family"`
+		Context string    `json:"context"`
+		Type    string    `json:"type"`
+		Labels  []string  `json:"labels,omitempty"`
+		Dims    []dimDump `json:"dimensions"`
+	}
+	dimDump struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Algo string `json:"algorithm"`
+		Mul  int    `json:"multiplier"`
+		Div  int    `json:"divisor"`
+	}
+)
+
+// Dump writes the config schema, the defaults and the static charts of the registered modules
+// (filtered by RunModule) in the given format ('json' or 'markdown').
+func (a *Agent) Dump(w io.Writer, format string) error {
+	var dumps []moduleDump
+	for name, creator := range a.ModuleRegistry {
+		if a.RunModule != "" && a.RunModule != "all" && a.RunModule != name {
+			continue
+		}
+		dumps = append(dumps, dumpModule(name, creator))
+	}
+	sort.Slice(dumps, func(i, j int) bool { return dumps[i].Name < dumps[j].Name })
+
+	switch format {
+	case dumpFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(dumps)
+	case dumpFormatMarkdown:
+		return writeMarkdownDump(w, dumps)
+	default:
+		return fmt.Errorf("unknown dump format '%s'", format)
+	}
+}
+
+func dumpModule(name string, creator module.Creator) moduleDump {
+	d := moduleDump{
+		Name: name,
+		Defaults: moduleDefaults{
+			UpdateEvery:        defaults.FirstPositive(creator.UpdateEvery, module.UpdateEvery),
+			AutoDetectionRetry: creator.AutoDetectionRetry,
+			Priority:           defaults.FirstPositive(creator.Priority, module.Priority),
+			Disabled:           creator.Disabled,
+			Backoff:            creator.Backoff,
+		},
+	}
+	if creator.JobConfigSchema != "" && json.Valid([]byte(creator.JobConfigSchema)) {
+		d.ConfigSchema = json.RawMessage(creator.JobConfigSchema)
+	}
+
+	charts, err := staticCharts(creator)
+	if err != nil {
+		d.ChartsError = err.Error()
+	}
+	for _, chart := range charts {
+		cd := chartDump{
+			ID:      chart.ID,
+			Title:   chart.Title,
+			Units:   chart.Units,
+			Family:  chart.Fam,
+			Context: chart.Ctx,
+			Type:    chart.Type.String(),
+		}
+		for _, l := range chart.Labels {
+			cd.Labels = append(cd.Labels, l.Key)
+		}
+		for _, dim := range chart.Dims {
+			cd.Dims = append(cd.Dims, dimDump{
+				ID:   dim.ID,
+				Name: dim.Name,
+				Algo: dim.Algo.String(),
+				Mul:  defaults.HandleZero(dim.Mul),
+				Div:  defaults.HandleZero(dim.Div),
+			})
+		}
+		d.Charts = append(d.Charts, cd)
+	}
+	return d
+}
+
+// staticCharts returns the charts of a module instance with the default config.
+// Init isn't called (it may do I/O), the charts created in Init are not a part of the dump.
+func staticCharts(creator module.Creator) (charts module.Charts, err error) {
+	if creator.Create == nil {
+		return nil, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	if v := creator.Create().Charts(); v != nil {
+		return *v.Copy(), nil
+	}
+	return nil, nil
+}
+
+func writeMarkdownDump(w io.Writer, dumps []moduleDump) error {
+	var sb strings.Builder
+
+	for _, d := range dumps {
+		fmt.Fprintf(&sb, "## %s\n\n", d.Name)
+
+		sb.WriteString("### Defaults\n\n")
+		sb.WriteString("| Option | Value |\n|:-------|:------|\n")
+		fmt.Fprintf(&sb, "| update_every | %d |\n", d.Defaults.UpdateEvery)
+		fmt.Fprintf(&sb, "| autodetection_retry | %d |\n", d.Defaults.AutoDetectionRetry)
+		fmt.Fprintf(&sb, "| priority | %d |\n", d.Defaults.Priority)
+		fmt.Fprintf(&sb, "| disabled | %v |\n", d.Defaults.Disabled)
+		if d.Defaults.Backoff != (module.Backoff{}) {
+			fmt.Fprintf(&sb, "| backoff | %s, max %ds |\n", d.Defaults.Backoff.Policy, d.Defaults.Backoff.Max)
+		}
+		sb.WriteString("\n")
+
+		sb.WriteString("### Charts\n\n")
+		if d.ChartsError != "" {
+			fmt.Fprintf(&sb, "Couldn't get the charts: %s.\n\n", d.ChartsError)
+		} else if len(d.Charts) == 0 {
+			sb.WriteString("No static charts, the charts are created at runtime.\n\n")
+		}
+		if len(d.Charts) > 0 {
+			sb.WriteString("| Context | Chart | Title | Units | Type | Dimensions | Labels |\n")
+			sb.WriteString("|:--------|:------|:------|:------|:-----|:-----------|:-------|\n")
+			for _, c := range d.Charts {
+				var dims []string
+				for _, dim := range c.Dims {
+					dims = append(dims, defaults.FirstNotEmpty(dim.Name, dim.ID)+" ("+dim.Algo+")")
+				}
+				fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s | %s |\n",
+					mdEscape(c.Context), mdEscape(c.ID), mdEscape(c.Title), mdEscape(c.Units), c.Type,
+					mdEscape(strings.Join(dims, ", ")), mdEscape(strings.Join(c.Labels, ", ")))
+			}
+			sb.WriteString("\n")
+		}
+
+		if len(d.ConfigSchema) > 0 {
+			var indented strings.Builder
+			var v any
+			if err := json.Unmarshal(d.ConfigSchema, &v); err == nil {
+				bs, _ := json.MarshalIndent(v, "", "  ")
+				indented.Write(bs)
+			}
+			sb.WriteString("### Config schema\n\n```json\n" + indented.String() + "\n```\n\n")
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
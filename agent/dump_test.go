@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/netdata/go.d.plugin/agent/module"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func prepareDumpRegistry() module.Registry {
+	charts := func() *module.Charts {
+		return &module.Charts{
+			{
+				ID:    "requests",
+				Title: "Requests",
+				Units: "requests/s",
+				Fam:   "requests",
+				Ctx:   "module1.requests",
+				Dims: module.Dims{
+					{ID: "requests_total", Name: "total", Algo: module.Incremental},
+				},
+			},
+		}
+	}
+	return module.Registry{
+		"module1": module.Creator{
+			Defaults:        module.Defaults{UpdateEvery: 5},
+			JobConfigSchema: `{"type": "object"}`,
+			Create: func() module.Module {
+				return &module.MockModule{ChartsFunc: charts}
+			},
+		},
+		"module2": module.Creator{
+			Create: func() module.Module {
+				return &module.MockModule{InitFunc: func() bool { panic("Init must not be called") }}
+			},
+		},
+	}
+}
+
+func TestAgent_Dump_JSON(t *testing.T) {
+	a := New(Config{Name: "test"})
+	a.ModuleRegistry = prepareDumpRegistry()
+
+	var buf bytes.Buffer
+	require.NoError(t, a.Dump(&buf, dumpFormatJSON))
+
+	var dumps []moduleDump
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &dumps))
+	require.Len(t, dumps, 2)
+
+	expected := moduleDump{
+		Name: "module1",
+		Defaults: moduleDefaults{
+			UpdateEvery: 5,
+			Priority:    module.Priority,
+		},
+		Charts: []chartDump{
+			{
+				ID:      "requests",
+				Title:   "Requests",
+				Units:   "requests/s",
+				Family:  "requests",
+				Context: "module1.requests",
+				Type:    "line",
+				Dims: []dimDump{
+					{ID: "requests_total", Name: "total", Algo: "incremental", Mul: 1, Div: 1},
+				},
+			},
+		},
+	}
+	assert.JSONEq(t, `{"type": "object"}`, string(dumps[0].ConfigSchema))
+	dumps[0].ConfigSchema = nil
+	assert.Equal(t, expected, dumps[0])
+	assert.Equal(t, "module2", dumps[1].Name)
+	assert.Empty(t, dumps[1].Charts)
+	assert.Empty(t, dumps[1].ChartsError)
+	assert.Contains(t, buf.String(), `"backoff": {
+        "policy": "",
+        "max": 0
+      }`)
+}
+
+func TestAgent_Dump_Markdown(t *testing.T) {
+	a := New(Config{Name: "test", RunModule: "module1"})
+	a.ModuleRegistry = prepareDumpRegistry()
+
+	var buf bytes.Buffer
+	require.NoError(t, a.Dump(&buf, dumpFormatMarkdown))
+
+	out := buf.String()
+	assert.Contains(t, out, "## module1\n")
+	assert.NotContains(t, out, "## module2\n")
+	assert.Contains(t, out, "| update_every | 5 |\n")
+	assert.Contains(t, out, "| module1.requests | requests | Requests | requests/s | line | total (incremental) |  |\n")
+	assert.Contains(t, out, "```json\n{\n  \"type\": \"object\"\n}\n```\n")
+}
+
+func TestAgent_Dump_UnknownFormat(t *testing.T) {
+	a := New(Config{Name: "test"})
+	a.ModuleRegistry = prepareDumpRegistry()
+
+	assert.Error(t, a.Dump(&bytes.Buffer{}, "yaml"))
+}
@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package defaults has the helpers that pick the first set value out of the configured and the default ones.
+package defaults
+
+// FirstPositive returns the first positive value, or the last value if there are no positive values.
+func FirstPositive(value int, others ...int) int {
+	if value > 0 || len(others) == 0 {
+		return value
+	}
+	return FirstPositive(others[0], others[1:]...)
+}
+
+// FirstNotEmpty returns the first non-empty value, or the last value if all values are empty.
+func FirstNotEmpty(value string, others ...string) string {
+	if value != "" || len(others) == 0 {
+		return value
+	}
+	return FirstNotEmpty(others[0], others[1:]...)
+}
+
+// HandleZero returns 1 (the default dimension multiplier and divisor) if the value is zero.
+func HandleZero(v int) int {
+	if v == 0 {
+		return 1
+	}
+	return v
+}
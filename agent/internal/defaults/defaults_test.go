@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package defaults
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirstPositive(t *testing.T) {
+	assert.Equal(t, 1, FirstPositive(1, 2))
+	assert.Equal(t, 2, FirstPositive(0, 2))
+	assert.Equal(t, 3, FirstPositive(-1, 0, 3))
+	assert.Equal(t, 0, FirstPositive(-1, 0))
+	assert.Equal(t, -1, FirstPositive(-1))
+}
+
+func TestFirstNotEmpty(t *testing.T) {
+	assert.Equal(t, "a", FirstNotEmpty("a", "b"))
+	assert.Equal(t, "b", FirstNotEmpty("", "b"))
+	assert.Equal(t, "c", FirstNotEmpty("", "", "c"))
+	assert.Equal(t, "", FirstNotEmpty("", ""))
+}
+
+func TestHandleZero(t *testing.T) {
+	assert.Equal(t, 1, HandleZero(0))
+	assert.Equal(t, -1, HandleZero(-1))
+	assert.Equal(t, 1000, HandleZero(1000))
+}
@@ -21,9 +21,10 @@ type (
 	retryingJobsCache map[uint64]retryTask
 
 	retryTask struct {
-		cancel  context.CancelFunc
-		timeout int
-		retries int
+		cancel   context.CancelFunc
+		timeout  int
+		retries  int
+		attempts int // the number of the retries made so far
 	}
 )
 
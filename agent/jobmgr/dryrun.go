@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package jobmgr
+
+import (
+	"fmt"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/module"
+)
+
+// DryRun builds the job the same way the manager does and runs Init, Check and one Collect.
+// Nothing is written to the output.
+func (m *Manager) DryRun(cfg confgroup.Config) module.DryRunResult {
+	job, err := m.createJob(cfg)
+	if err != nil {
+		return module.DryRunResult{Err: fmt.Errorf("create: %v", err)}
+	}
+	return job.DryRun()
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package jobmgr
+
+import (
+	"testing"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/module"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_DryRun(t *testing.T) {
+	reg := prepareMockRegistry()
+	reg.Register("panic", module.Creator{
+		Create: func() module.Module {
+			return &module.MockModule{
+				ChartsFunc:  func() *module.Charts { return &module.Charts{} },
+				CollectFunc: func() map[string]int64 { panic("panic in Collect") },
+			}
+		},
+	})
+
+	tests := map[string]struct {
+		cfg         confgroup.Config
+		wantErr     string
+		wantCharts  int
+		wantMetrics map[string]int64
+	}{
+		"success": {
+			cfg:         confgroup.Config{"name": "job", "module": "success", "update_every": 1},
+			wantCharts:  1,
+			wantMetrics: map[string]int64{"id1": 1},
+		},
+		"init fails": {
+			cfg:     confgroup.Config{"name": "job", "module": "fail", "update_every": 1},
+			wantErr: "init failed",
+		},
+		"panic in collect": {
+			cfg:     confgroup.Config{"name": "job", "module": "panic", "update_every": 1},
+			wantErr: "panic: panic in Collect",
+		},
+		"unknown module": {
+			cfg:     confgroup.Config{"name": "job", "module": "unknown", "update_every": 1},
+			wantErr: "create: can not find unknown module",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mgr := NewManager()
+			mgr.Modules = reg
+
+			res := mgr.DryRun(test.cfg)
+
+			if test.wantErr != "" {
+				assert.EqualError(t, res.Err, test.wantErr)
+			} else {
+				assert.NoError(t, res.Err)
+				assert.Equal(t, test.wantCharts, res.Charts)
+				assert.Equal(t, test.wantMetrics, res.Metrics)
+			}
+		})
+	}
+}
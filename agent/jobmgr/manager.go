@@ -5,6 +5,7 @@ package jobmgr
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"log/slog"
 	"os"
@@ -29,6 +30,7 @@ type Job interface {
 	Tick(clock int)
 	Start()
 	Stop()
+	Abandon()
 	Cleanup()
 }
 
@@ -42,6 +44,7 @@ const (
 	jobStatusStoppedDupGlobal jobStatus = "stopped_duplicate_global"   // a job with the same FullName is registered by another plugin
 	jobStatusStoppedRegErr    jobStatus = "stopped_registration_error" // an error during registration (only 'too many open files')
 	jobStatusStoppedCreateErr jobStatus = "stopped_creation_error"     // an error during creation (yaml unmarshal)
+	jobStatusStuck            jobStatus = "stuck"                      // Collect() is running longer than collect_timeout
 )
 
 func NewManager() *Manager {
@@ -58,12 +61,14 @@ func NewManager() *Manager {
 		Dyncfg:      np,
 
 		confGroupCache: confgroup.NewCache(),
+		telemetry:      newTelemetry(),
 
 		runningJobs:  newRunningJobsCache(),
 		retryingJobs: newRetryingJobsCache(),
 
 		addCh:    make(chan confgroup.Config),
 		removeCh: make(chan confgroup.Config),
+		stuckCh:  make(chan stuckJob),
 	}
 
 	return mgr
@@ -75,6 +80,8 @@ type Manager struct {
 	PluginName string
 	Out        io.Writer
 	Modules    module.Registry
+	// OutputFormat is the format of the collected data, the plugins.d protocol or netdataapi.FormatJSONL.
+	OutputFormat string
 
 	FileLock    FileLocker
 	StatusSaver StatusSaver
@@ -82,12 +89,26 @@ type Manager struct {
 	Vnodes      Vnodes
 	Dyncfg      Dyncfg
 
+	// MaxConcurrentCollections limits the number of jobs collecting data at the same time, 0 means no limit.
+	MaxConcurrentCollections int
+	// TickJitter spreads the data collections of the jobs that are due on the same tick.
+	TickJitter time.Duration
+
+	// Exporter, if set, receives the values collected by all the jobs.
+	Exporter module.MetricsExporter
+
+	collectLimiter module.CollectLimiter
+
+	modulesMux sync.RWMutex
+
 	confGroupCache *confgroup.Cache
+	telemetry      *telemetry
 	runningJobs    *runningJobsCache
 	retryingJobs   *retryingJobsCache
 
 	addCh    chan confgroup.Config
 	removeCh chan confgroup.Config
+	stuckCh  chan stuckJob
 
 	queueMux sync.Mutex
 	queue    []Job
@@ -97,6 +118,8 @@ func (m *Manager) Run(ctx context.Context, in chan []*confgroup.Group) {
 	m.Info("instance is started")
 	defer func() { m.cleanup(); m.Info("instance is stopped") }()
 
+	m.collectLimiter = module.NewCollectLimiter(m.MaxConcurrentCollections)
+
 	var wg sync.WaitGroup
 
 	wg.Add(1)
@@ -108,6 +131,9 @@ func (m *Manager) Run(ctx context.Context, in chan []*confgroup.Group) {
 	wg.Add(1)
 	go func() { defer wg.Done(); m.runRunningJobsHandling(ctx) }()
 
+	wg.Add(1)
+	go func() { defer wg.Done(); m.runTelemetry(ctx) }()
+
 	wg.Wait()
 	<-ctx.Done()
 }
@@ -124,6 +150,7 @@ func (m *Manager) runConfigGroupsHandling(ctx context.Context, in chan []*confgr
 					return
 				default:
 					a, r := m.confGroupCache.Add(gr)
+					m.telemetry.addConfigGroup(len(a), len(r))
 					m.Debugf("received config group ('%s'): %d jobs (added: %d, removed: %d)", gr.Source, len(gr.Configs), len(a), len(r))
 					sendConfigs(ctx, m.removeCh, r)
 					sendConfigs(ctx, m.addCh, a)
@@ -142,6 +169,8 @@ func (m *Manager) runConfigsHandling(ctx context.Context) {
 			m.addConfig(ctx, cfg)
 		case cfg := <-m.removeCh:
 			m.removeConfig(cfg)
+		case v := <-m.stuckCh:
+			m.handleStuckJob(ctx, v)
 		}
 	}
 }
@@ -168,7 +197,7 @@ func (m *Manager) addConfig(ctx context.Context, cfg confgroup.Config) {
 
 	if m.runningJobs.has(cfg) {
 		m.Infof("%s[%s] job is being served by another job, skipping it", cfg.Module(), cfg.Name())
-		m.StatusSaver.Save(cfg, jobStatusStoppedDupLocal)
+		m.saveStatus(cfg, jobStatusStoppedDupLocal)
 		m.Dyncfg.UpdateStatus(cfg, "error", "duplicate, served by another job")
 		return
 	}
@@ -176,7 +205,7 @@ func (m *Manager) addConfig(ctx context.Context, cfg confgroup.Config) {
 	job, err := m.createJob(cfg)
 	if err != nil {
 		m.Warningf("couldn't create %s[%s]: %v", cfg.Module(), cfg.Name(), err)
-		m.StatusSaver.Save(cfg, jobStatusStoppedCreateErr)
+		m.saveStatus(cfg, jobStatusStoppedCreateErr)
 		m.Dyncfg.UpdateStatus(cfg, "error", fmt.Sprintf("build error: %s", err))
 		return
 	}
@@ -193,14 +222,12 @@ func (m *Manager) addConfig(ctx context.Context, cfg confgroup.Config) {
 		job.AutoDetectTries = task.retries
 	} else if job.AutoDetectionEvery() == 0 {
 		switch {
-		case m.StatusStore.Contains(cfg, jobStatusRunning, jobStatusRetrying):
-			m.Infof("%s[%s] job last status is running/retrying, applying recovering settings", cfg.Module(), cfg.Name())
-			job.AutoDetectEvery = 30
-			job.AutoDetectTries = 11
+		case m.StatusStore.Contains(cfg, jobStatusRunning, jobStatusRetrying, jobStatusStuck):
+			m.Infof("%s[%s] job last status is running/retrying/stuck, applying recovering settings", cfg.Module(), cfg.Name())
+			applyRecoverySettings(job, cfg, recoveryEvery)
 		case isInsideK8sCluster() && cfg.Provider() == "file watcher":
 			m.Infof("%s[%s] is k8s job, applying recovering settings", cfg.Module(), cfg.Name())
-			job.AutoDetectEvery = 10
-			job.AutoDetectTries = 7
+			applyRecoverySettings(job, cfg, k8sRecoveryEvery)
 		}
 	}
 
@@ -209,37 +236,61 @@ func (m *Manager) addConfig(ctx context.Context, cfg confgroup.Config) {
 		if ok, err := m.FileLock.Lock(cfg.FullName()); ok || err != nil && !isTooManyOpenFiles(err) {
 			cleanupJob = false
 			m.runningJobs.put(cfg)
-			m.StatusSaver.Save(cfg, jobStatusRunning)
+			m.saveStatus(cfg, jobStatusRunning)
 			m.Dyncfg.UpdateStatus(cfg, "running", "")
+			job.OnStuck = func(stuck bool) {
+				sendStuckJob(ctx, job.Done(), m.stuckCh, stuckJob{job: job, cfg: cfg, stuck: stuck})
+			}
 			m.startJob(job)
 		} else if isTooManyOpenFiles(err) {
 			m.Error(err)
-			m.StatusSaver.Save(cfg, jobStatusStoppedRegErr)
+			m.saveStatus(cfg, jobStatusStoppedRegErr)
 			m.Dyncfg.UpdateStatus(cfg, "error", "too many open files")
 		} else {
 			m.Infof("%s[%s] job is being served by another plugin, skipping it", cfg.Module(), cfg.Name())
-			m.StatusSaver.Save(cfg, jobStatusStoppedDupGlobal)
+			m.saveStatus(cfg, jobStatusStoppedDupGlobal)
 			m.Dyncfg.UpdateStatus(cfg, "error", "duplicate, served by another plugin")
 		}
 	case jobStatusRetrying:
-		m.Infof("%s[%s] job detection failed, will retry in %d seconds", cfg.Module(), cfg.Name(), job.AutoDetectionEvery())
+		var attempts int
+		if isRetry {
+			attempts = task.attempts + 1
+		}
+		delay := cfg.Backoff().Delay(job.AutoDetectionEvery(), attempts)
+		m.Infof("%s[%s] job detection failed, will retry in %d seconds", cfg.Module(), cfg.Name(), delay)
 		ctx, cancel := context.WithCancel(ctx)
 		m.retryingJobs.put(cfg, retryTask{
-			cancel:  cancel,
-			timeout: job.AutoDetectionEvery(),
-			retries: job.AutoDetectTries,
+			cancel:   cancel,
+			timeout:  job.AutoDetectionEvery(),
+			retries:  job.AutoDetectTries,
+			attempts: attempts,
 		})
-		go runRetryTask(ctx, m.addCh, cfg, time.Second*time.Duration(job.AutoDetectionEvery()))
-		m.StatusSaver.Save(cfg, jobStatusRetrying)
+		go runRetryTask(ctx, m.addCh, cfg, time.Second*time.Duration(delay))
+		m.saveStatus(cfg, jobStatusRetrying)
 		m.Dyncfg.UpdateStatus(cfg, "error", "job detection failed, will retry later")
 	case jobStatusStoppedFailed:
-		m.StatusSaver.Save(cfg, jobStatusStoppedFailed)
+		m.saveStatus(cfg, jobStatusStoppedFailed)
 		m.Dyncfg.UpdateStatus(cfg, "error", "job detection failed, stopping it")
 	default:
 		m.Warningf("%s[%s] job detection: unknown state", cfg.Module(), cfg.Name())
 	}
 }
 
+// The base intervals (seconds) of the autodetection retries of the jobs that have no autodetection_retry.
+const (
+	recoveryEvery    = 30 // the job was running before the restart
+	k8sRecoveryEvery = 10
+)
+
+// applyRecoverySettings makes the job that has no autodetection_retry retry the autodetection anyway.
+// The retries start at the base interval (or the job update every if it is longer) and follow the job backoff
+// policy, until the sum of the delays reaches the backoff max.
+func applyRecoverySettings(job *module.Job, cfg confgroup.Config, baseEvery int) {
+	every := max(cfg.UpdateEvery(), baseEvery)
+	job.AutoDetectEvery = every
+	job.AutoDetectTries = cfg.Backoff().Retries(every)
+}
+
 func (m *Manager) removeConfig(cfg confgroup.Config) {
 	if m.runningJobs.has(cfg) {
 		m.stopJob(cfg.FullName())
@@ -252,18 +303,81 @@ func (m *Manager) removeConfig(cfg confgroup.Config) {
 		m.retryingJobs.remove(cfg)
 	}
 
-	m.StatusSaver.Remove(cfg)
+	m.removeStatus(cfg)
 	m.Dyncfg.Unregister(cfg)
 }
 
+type stuckJob struct {
+	job   Job // the job instance the watchdog belongs to, the config can be served by a new one already
+	cfg   confgroup.Config
+	stuck bool
+}
+
+func (m *Manager) handleStuckJob(ctx context.Context, v stuckJob) {
+	cfg := v.cfg
+	if !m.runningJobs.has(cfg) || !m.isJobQueued(v.job) {
+		return
+	}
+
+	if !v.stuck {
+		m.Infof("%s[%s] job data collection is no longer stuck", cfg.Module(), cfg.Name())
+		m.saveStatus(cfg, jobStatusRunning)
+		m.Dyncfg.UpdateStatus(cfg, "running", "")
+		return
+	}
+
+	m.Warningf("%s[%s] job data collection is stuck (collect_timeout %ds)", cfg.Module(), cfg.Name(), cfg.CollectTimeout())
+	m.saveStatus(cfg, jobStatusStuck)
+	m.Dyncfg.UpdateStatus(cfg, "error", "data collection is stuck")
+
+	if !cfg.RestartStuck() {
+		return
+	}
+
+	m.Infof("%s[%s] restarting the stuck job", cfg.Module(), cfg.Name())
+	m.abandonJob(v.job)
+	_ = m.FileLock.Unlock(cfg.FullName())
+	m.runningJobs.remove(cfg)
+	m.addConfig(ctx, cfg)
+}
+
+// SetModules replaces the modules the jobs are created for. It is safe to call while the manager is running,
+// the already running jobs are not affected.
+func (m *Manager) SetModules(reg module.Registry) {
+	m.modulesMux.Lock()
+	defer m.modulesMux.Unlock()
+	m.Modules = reg
+}
+
+func (m *Manager) lookupModule(name string) (module.Creator, bool) {
+	m.modulesMux.RLock()
+	defer m.modulesMux.RUnlock()
+	creator, ok := m.Modules[name]
+	return creator, ok
+}
+
+func (m *Manager) saveStatus(cfg confgroup.Config, status jobStatus) {
+	m.telemetry.setStatus(cfg, status)
+	m.StatusSaver.Save(cfg, status)
+}
+
+func (m *Manager) removeStatus(cfg confgroup.Config) {
+	m.telemetry.removeStatus(cfg)
+	m.StatusSaver.Remove(cfg)
+}
+
 func (m *Manager) createJob(cfg confgroup.Config) (*module.Job, error) {
-	creator, ok := m.Modules[cfg.Module()]
+	creator, ok := m.lookupModule(cfg.Module())
 	if !ok {
 		return nil, fmt.Errorf("can not find %s module", cfg.Module())
 	}
 
 	m.Debugf("creating %s[%s] job, config: %v", cfg.Module(), cfg.Name(), cfg)
 
+	if err := cfg.Backoff().Validate(); err != nil {
+		return nil, err
+	}
+
 	mod := creator.Create()
 	if err := unmarshal(cfg, mod); err != nil {
 		return nil, err
@@ -284,12 +398,21 @@ func (m *Manager) createJob(cfg confgroup.Config) (*module.Job, error) {
 		ModuleName:      cfg.Module(),
 		FullName:        cfg.FullName(),
 		UpdateEvery:     cfg.UpdateEvery(),
+		Interval:        time.Duration(cfg.UpdateEveryMs()) * time.Millisecond,
+		PhaseOffset:     phaseOffset(cfg),
 		AutoDetectEvery: cfg.AutoDetectionRetry(),
 		Priority:        cfg.Priority(),
 		Labels:          labels,
 		IsStock:         isStockConfig(cfg),
 		Module:          mod,
 		Out:             m.Out,
+		OutputFormat:    m.OutputFormat,
+		CollectLimiter:  m.collectLimiter,
+		TickJitter:      m.TickJitter,
+		CollectTimeout:  time.Duration(cfg.CollectTimeout()) * time.Second,
+		Backoff:         cfg.Backoff(),
+		RuntimeStats:    m.telemetry.runtimeStats,
+		Exporter:        m.Exporter,
 	}
 
 	if cfg.Vnode() != "" {
@@ -308,6 +431,27 @@ func (m *Manager) createJob(cfg confgroup.Config) (*module.Job, error) {
 	return job, nil
 }
 
+// phaseOffset returns the job phase offset. The 'auto' offset is derived from the job full name,
+// it spreads the jobs within the interval and doesn't change between restarts.
+func phaseOffset(cfg confgroup.Config) time.Duration {
+	ms, auto := cfg.PhaseOffsetMs()
+	if !auto {
+		return time.Duration(max(ms, 0)) * time.Millisecond
+	}
+
+	interval := cfg.UpdateEveryMs()
+	if interval <= 0 {
+		interval = cfg.UpdateEvery() * 1000
+	}
+	if interval <= 0 {
+		return 0
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(cfg.FullName()))
+	return time.Duration(h.Sum32()%uint32(interval)) * time.Millisecond
+}
+
 func detection(job Job) jobStatus {
 	if !job.AutoDetection() {
 		if job.RetryAutoDetection() {
@@ -330,6 +474,15 @@ func runRetryTask(ctx context.Context, out chan<- confgroup.Config, cfg confgrou
 	}
 }
 
+// sendStuckJob gives up if the job is stopped (or abandoned) before the manager receives the notification.
+func sendStuckJob(ctx context.Context, jobDone <-chan struct{}, out chan<- stuckJob, v stuckJob) {
+	select {
+	case <-ctx.Done():
+	case <-jobDone:
+	case out <- v:
+	}
+}
+
 func sendConfigs(ctx context.Context, out chan<- confgroup.Config, cfgs []confgroup.Config) {
 	for _, cfg := range cfgs {
 		sendConfig(ctx, out, cfg)
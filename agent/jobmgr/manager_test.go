@@ -5,6 +5,8 @@ package jobmgr
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"sync"
 	"testing"
 	"time"
@@ -13,6 +15,7 @@ import (
 	"github.com/netdata/go.d.plugin/agent/module"
 	"github.com/netdata/go.d.plugin/agent/safewriter"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TODO: tech dept
@@ -102,3 +105,205 @@ func prepareMockRegistry() module.Registry {
 	})
 	return reg
 }
+
+func TestApplyRecoverySettings(t *testing.T) {
+	tests := map[string]struct {
+		cfg       confgroup.Config
+		baseEvery int
+		wantEvery int
+		wantTries int
+	}{
+		"default backoff": {
+			cfg:       confgroup.Config{"update_every": 1},
+			baseEvery: recoveryEvery,
+			wantEvery: 30,
+			wantTries: 9,
+		},
+		"k8s job": {
+			cfg:       confgroup.Config{"update_every": 1},
+			baseEvery: k8sRecoveryEvery,
+			wantEvery: 10,
+			wantTries: 15,
+		},
+		"update_every is longer than the base interval": {
+			cfg:       confgroup.Config{"update_every": 60},
+			baseEvery: recoveryEvery,
+			wantEvery: 60,
+			wantTries: 6,
+		},
+		"backoff max": {
+			cfg:       confgroup.Config{"update_every": 1, "backoff": map[any]any{"policy": "none", "max": 60}},
+			baseEvery: recoveryEvery,
+			wantEvery: 30,
+			wantTries: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			job := module.NewJob(module.JobConfig{Out: io.Discard})
+
+			applyRecoverySettings(job, test.cfg, test.baseEvery)
+
+			assert.Equal(t, test.wantEvery, job.AutoDetectEvery)
+			assert.Equal(t, test.wantTries, job.AutoDetectTries)
+		})
+	}
+}
+
+func TestManager_handleStuckJob(t *testing.T) {
+	tests := map[string]struct {
+		restart      bool
+		wantStatuses []string
+	}{
+		"stuck, no restart": {
+			restart:      false,
+			wantStatuses: []string{jobStatusRunning, jobStatusStuck, jobStatusRunning},
+		},
+		"stuck, restart": {
+			restart: true,
+			// the recovery notification of the abandoned job is ignored
+			wantStatuses: []string{jobStatusRunning, jobStatusStuck, jobStatusRunning},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			saver := &recordingStatusSaver{}
+			mgr := NewManager()
+			mgr.Modules = prepareMockRegistry()
+			mgr.StatusSaver = saver
+
+			cfg := confgroup.Config{
+				"name":                "name",
+				"module":              "success",
+				"update_every":        module.UpdateEvery,
+				"autodetection_retry": module.AutoDetectionRetry,
+				"priority":            module.Priority,
+				"collect_timeout":     1,
+				"restart_stuck":       test.restart,
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			mgr.addConfig(ctx, cfg)
+			assert.Len(t, mgr.queue, 1)
+			job := mgr.queue[0]
+
+			mgr.handleStuckJob(ctx, stuckJob{job: job, cfg: cfg, stuck: true})
+			mgr.handleStuckJob(ctx, stuckJob{job: job, cfg: cfg, stuck: false})
+
+			assert.Len(t, mgr.queue, 1)
+			if test.restart {
+				assert.NotSame(t, job, mgr.queue[0])
+			} else {
+				assert.Same(t, job, mgr.queue[0])
+			}
+			assert.True(t, mgr.runningJobs.has(cfg))
+			assert.Equal(t, test.wantStatuses, saver.statuses)
+
+			mgr.stopRunningJobs()
+		})
+	}
+}
+
+func TestManager_handleStuckJob_StaleNotification(t *testing.T) {
+	saver := &recordingStatusSaver{}
+	mgr := NewManager()
+	mgr.Modules = prepareMockRegistry()
+	mgr.StatusSaver = saver
+
+	cfg := confgroup.Config{
+		"name":                "name",
+		"module":              "success",
+		"update_every":        module.UpdateEvery,
+		"autodetection_retry": module.AutoDetectionRetry,
+		"priority":            module.Priority,
+		"collect_timeout":     1,
+		"restart_stuck":       true,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mgr.addConfig(ctx, cfg)
+	require.Len(t, mgr.queue, 1)
+	stale := mgr.queue[0]
+
+	// the job is replaced (e.g. the config was removed and added back)
+	mgr.removeConfig(cfg)
+	mgr.addConfig(ctx, cfg)
+	require.Len(t, mgr.queue, 1)
+	job := mgr.queue[0]
+
+	// the notification of the replaced job doesn't affect the new one
+	mgr.handleStuckJob(ctx, stuckJob{job: stale, cfg: cfg, stuck: true})
+
+	assert.Same(t, job, mgr.queue[0])
+	assert.NotContains(t, saver.statuses, jobStatusStuck)
+
+	mgr.stopRunningJobs()
+}
+
+func TestSendStuckJob_JobDone(t *testing.T) {
+	jobDone := make(chan struct{})
+	close(jobDone)
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		// nobody receives the notification
+		sendStuckJob(context.Background(), jobDone, make(chan stuckJob), stuckJob{stuck: true})
+	}()
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("sendStuckJob is blocked after the job is done")
+	}
+}
+
+type recordingStatusSaver struct {
+	statuses []string
+}
+
+func (s *recordingStatusSaver) Save(_ confgroup.Config, status string) {
+	s.statuses = append(s.statuses, status)
+}
+func (s *recordingStatusSaver) Remove(confgroup.Config) {}
+
+func TestPhaseOffset(t *testing.T) {
+	tests := map[string]struct {
+		cfg  confgroup.Config
+		want time.Duration
+	}{
+		"not set":  {cfg: confgroup.Config{"update_every": 1}},
+		"ms":       {cfg: confgroup.Config{"update_every": 1, "phase_offset_ms": 300}, want: time.Millisecond * 300},
+		"negative": {cfg: confgroup.Config{"update_every": 1, "phase_offset_ms": -300}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, phaseOffset(test.cfg))
+		})
+	}
+}
+
+func TestPhaseOffset_Auto(t *testing.T) {
+	offsets := make(map[time.Duration]bool)
+	for i := 0; i < 10; i++ {
+		cfg := confgroup.Config{
+			"name":            fmt.Sprintf("job%d", i),
+			"module":          "module",
+			"update_every":    1,
+			"update_every_ms": 500,
+			"phase_offset_ms": "auto",
+		}
+		offset := phaseOffset(cfg)
+		assert.Equal(t, offset, phaseOffset(cfg), "auto offset is not stable")
+		assert.True(t, offset >= 0 && offset < time.Millisecond*500, "auto offset %s is out of the interval", offset)
+		offsets[offset] = true
+	}
+	assert.Greater(t, len(offsets), 1, "auto offsets are not spread")
+}
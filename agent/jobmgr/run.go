@@ -5,13 +5,13 @@ package jobmgr
 import (
 	"context"
 	"slices"
-	"time"
 
+	"github.com/netdata/go.d.plugin/agent/module"
 	"github.com/netdata/go.d.plugin/agent/ticker"
 )
 
 func (m *Manager) runRunningJobsHandling(ctx context.Context) {
-	tk := ticker.New(time.Second)
+	tk := ticker.New(module.SchedulerTick)
 	defer tk.Stop()
 
 	for {
@@ -61,6 +61,30 @@ func (m *Manager) stopJob(name string) {
 	}
 }
 
+// abandonJob removes the job from the queue without waiting for it to stop.
+func (m *Manager) abandonJob(job Job) {
+	m.queueMux.Lock()
+	defer m.queueMux.Unlock()
+
+	idx := slices.Index(m.queue, job)
+
+	if idx != -1 {
+		m.queue[idx].Abandon()
+
+		copy(m.queue[idx:], m.queue[idx+1:])
+		m.queue[len(m.queue)-1] = nil
+		m.queue = m.queue[:len(m.queue)-1]
+	}
+}
+
+// isJobQueued reports whether the job instance is in the queue (not stopped or replaced).
+func (m *Manager) isJobQueued(job Job) bool {
+	m.queueMux.Lock()
+	defer m.queueMux.Unlock()
+
+	return slices.Contains(m.queue, job)
+}
+
 func (m *Manager) stopRunningJobs() {
 	m.queueMux.Lock()
 	defer m.queueMux.Unlock()
@@ -0,0 +1,265 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package jobmgr
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/module"
+	"github.com/netdata/go.d.plugin/agent/netdataapi"
+)
+
+var ndInternalMonitoringDisabled = os.Getenv("NETDATA_INTERNALS_MONITORING") == "NO"
+
+const telemetryUpdateEvery = 1
+
+var reSpace = regexp.MustCompile(`\s+`)
+
+// telemetry keeps the plugin-wide stats and writes them as 'netdata' type charts.
+type telemetry struct {
+	mux            sync.Mutex
+	statuses       map[uint64]jobStatus // [cfg hash]
+	configGroups   int64
+	configsAdded   int64
+	configsRemoved int64
+
+	runtimeStats *module.RuntimeStats
+
+	charts  []*telemetryChart
+	prevRun time.Time
+	buf     bytes.Buffer
+	api     netdataapi.ChartAPI
+}
+
+func newTelemetry() *telemetry {
+	t := &telemetry{
+		statuses:     make(map[uint64]jobStatus),
+		runtimeStats: module.NewRuntimeStats(),
+	}
+	t.api = netdataapi.New(&t.buf)
+	return t
+}
+
+func (t *telemetry) setStatus(cfg confgroup.Config, status jobStatus) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.statuses[cfg.Hash()] = status
+}
+
+func (t *telemetry) removeStatus(cfg confgroup.Config) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	delete(t.statuses, cfg.Hash())
+}
+
+func (t *telemetry) addConfigGroup(added, removed int) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.configGroups++
+	t.configsAdded += int64(added)
+	t.configsRemoved += int64(removed)
+}
+
+func (m *Manager) runTelemetry(ctx context.Context) {
+	if ndInternalMonitoringDisabled {
+		return
+	}
+
+	m.telemetry.initCharts(m.PluginName)
+	m.telemetry.api = netdataapi.NewChartAPI(m.OutputFormat, &m.telemetry.buf, m.PluginName)
+
+	tk := time.NewTicker(time.Second * telemetryUpdateEvery)
+	defer tk.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-tk.C:
+			m.telemetry.write(m.Out, now)
+		}
+	}
+}
+
+func (t *telemetry) initCharts(pluginName string) {
+	// keep the same naming as the execution time chart, see module.newRuntimeChart
+	name := pluginName
+	if name == "go.d" {
+		name = "go"
+	}
+	name = reSpace.ReplaceAllString(name, "_") + "_plugin"
+
+	var prio = 145100
+	newChart := func(id, title, units, chartType, algo string, div int, dims ...string) *telemetryChart {
+		prio++
+		return &telemetryChart{
+			key:       id,
+			id:        name + "_" + id,
+			title:     title,
+			units:     units,
+			family:    pluginName,
+			ctx:       "netdata." + name + "_" + id,
+			chartType: chartType,
+			priority:  prio,
+			plugin:    pluginName,
+			algo:      algo,
+			div:       div,
+			dims:      dims,
+		}
+	}
+
+	t.charts = []*telemetryChart{
+		newChart("jobs", "Jobs by status", "jobs", "stacked", "absolute", 1,
+			jobStatusRunning,
+			jobStatusRetrying,
+			jobStatusStuck,
+			jobStatusStoppedFailed,
+			jobStatusStoppedDupLocal,
+			jobStatusStoppedDupGlobal,
+			jobStatusStoppedRegErr,
+			jobStatusStoppedCreateErr,
+		),
+		newChart("skipped_ticks", "Skipped data collection ticks", "ticks/s", "stacked", "incremental", 1),
+		newChart("collection_panics", "Auto-detection and data collection panics", "panics/s", "stacked", "incremental", 1),
+		newChart("discovery_churn", "Discovery config groups churn", "events/s", "line", "incremental", 1,
+			"groups", "configs_added", "configs_removed"),
+		newChart("goroutines", "Goroutines", "goroutines", "line", "absolute", 1, "goroutines"),
+		newChart("heap", "Heap memory", "bytes", "area", "absolute", 1, "heap_alloc", "heap_inuse"),
+		newChart("gc_pause", "GC pause time", "ms/s", "line", "incremental", 1e6, "gc_pause"),
+	}
+}
+
+func (t *telemetry) collect() map[string]map[string]int64 {
+	t.mux.Lock()
+	jobs := make(map[string]int64)
+	for _, v := range []jobStatus{
+		jobStatusRunning,
+		jobStatusRetrying,
+		jobStatusStuck,
+		jobStatusStoppedFailed,
+		jobStatusStoppedDupLocal,
+		jobStatusStoppedDupGlobal,
+		jobStatusStoppedRegErr,
+		jobStatusStoppedCreateErr,
+	} {
+		jobs[v] = 0
+	}
+	for _, v := range t.statuses {
+		jobs[v]++
+	}
+	churn := map[string]int64{
+		"groups":          t.configGroups,
+		"configs_added":   t.configsAdded,
+		"configs_removed": t.configsRemoved,
+	}
+	t.mux.Unlock()
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	return map[string]map[string]int64{
+		"jobs":              jobs,
+		"skipped_ticks":     t.runtimeStats.SkippedTicks(),
+		"collection_panics": t.runtimeStats.Panics(),
+		"discovery_churn":   churn,
+		"goroutines":        {"goroutines": int64(runtime.NumGoroutine())},
+		"heap":              {"heap_alloc": int64(ms.HeapAlloc), "heap_inuse": int64(ms.HeapInuse)},
+		"gc_pause":          {"gc_pause": int64(ms.PauseTotalNs)},
+	}
+}
+
+func (t *telemetry) write(out io.Writer, now time.Time) {
+	var sinceLastRun int
+	if !t.prevRun.IsZero() {
+		sinceLastRun = int(now.Sub(t.prevRun).Microseconds())
+	}
+	t.prevRun = now
+
+	mx := t.collect()
+	for _, chart := range t.charts {
+		chart.write(t.api, mx[chart.key], sinceLastRun)
+	}
+	_ = t.api.Flush()
+
+	_, _ = io.Copy(out, &t.buf)
+	t.buf.Reset()
+}
+
+type telemetryChart struct {
+	key       string // the key of the collected metrics
+	id        string
+	title     string
+	units     string
+	family    string
+	ctx       string
+	chartType string
+	priority  int
+	plugin    string
+	algo      string
+	div       int
+	dims      []string
+
+	created  bool
+	seenDims map[string]bool
+}
+
+func (c *telemetryChart) write(api netdataapi.ChartAPI, values map[string]int64, sinceLastRun int) {
+	if c.seenDims == nil {
+		c.seenDims = make(map[string]bool)
+	}
+
+	var newDims []string
+	for _, dim := range c.dims {
+		if !c.seenDims[dim] {
+			newDims = append(newDims, dim)
+		}
+	}
+	// modules appear dynamically, keep the order stable
+	var dynDims []string
+	for dim := range values {
+		if !c.seenDims[dim] && !slices.Contains(c.dims, dim) {
+			dynDims = append(dynDims, dim)
+		}
+	}
+	sort.Strings(dynDims)
+	newDims = append(newDims, dynDims...)
+
+	if len(newDims) > 0 {
+		_ = api.CHART("netdata", c.id, "", c.title, c.units, c.family, c.ctx, c.chartType, c.priority,
+			telemetryUpdateEvery, "", c.plugin, "")
+		for _, dim := range newDims {
+			_ = api.DIMENSION(dim, dim, c.algo, 1, c.div, "")
+			c.seenDims[dim] = true
+		}
+		_ = api.EMPTYLINE()
+		if !c.created {
+			sinceLastRun = 0
+		}
+		c.created = true
+	}
+
+	if !c.created {
+		return
+	}
+
+	_ = api.BEGIN("netdata", c.id, sinceLastRun)
+	dims := make([]string, 0, len(c.seenDims))
+	for dim := range c.seenDims {
+		dims = append(dims, dim)
+	}
+	sort.Strings(dims)
+	for _, dim := range dims {
+		_ = api.SET(dim, values[dim])
+	}
+	_ = api.END()
+}
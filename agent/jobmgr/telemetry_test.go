@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package jobmgr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelemetry_write(t *testing.T) {
+	tm := newTelemetry()
+	tm.initCharts("go.d")
+
+	tm.setStatus(confgroup.Config{"name": "job1", "module": "module1"}, jobStatusRunning)
+	tm.setStatus(confgroup.Config{"name": "job2", "module": "module1"}, jobStatusRunning)
+	tm.setStatus(confgroup.Config{"name": "job3", "module": "module2"}, jobStatusRetrying)
+	tm.removeStatus(confgroup.Config{"name": "job3", "module": "module2"})
+	tm.addConfigGroup(3, 1)
+
+	var buf bytes.Buffer
+	now := time.Now()
+	tm.write(&buf, now)
+	out := buf.String()
+
+	assert.Contains(t, out, "CHART 'netdata.go_plugin_jobs' '' 'Jobs by status' 'jobs' 'go.d' 'netdata.go_plugin_jobs' 'stacked'")
+	assert.Contains(t, out, "CHART 'netdata.go_plugin_goroutines'")
+	assert.Contains(t, out, "SET 'running' = 2\n")
+	assert.Contains(t, out, "SET 'retrying' = 0\n")
+	assert.Contains(t, out, "SET 'configs_added' = 3\n")
+	assert.Contains(t, out, "SET 'configs_removed' = 1\n")
+	// no modules seen yet, the per module charts are not created
+	assert.NotContains(t, out, "netdata.go_plugin_skipped_ticks")
+
+}
+
+func TestTelemetry_write_PerModuleCharts(t *testing.T) {
+	mgr := NewManager()
+	mgr.Modules = prepareMockRegistry()
+	mgr.telemetry.initCharts("go.d")
+
+	job, err := mgr.createJob(confgroup.Config{"name": "job1", "module": "success", "update_every": 1})
+	assert.NoError(t, err)
+	job.Tick(0) // the job is due but not started, the tick is skipped
+
+	var buf bytes.Buffer
+	now := time.Now()
+	mgr.telemetry.write(&buf, now)
+	mgr.telemetry.write(&buf, now.Add(time.Second))
+	out := buf.String()
+
+	assert.Equal(t, 1, strings.Count(out, "CHART 'netdata.go_plugin_skipped_ticks'"))
+	assert.Contains(t, out, "DIMENSION 'success' 'success' 'incremental' '1' '1' ''")
+	assert.Contains(t, out, "BEGIN 'netdata.go_plugin_skipped_ticks' 1000000\nSET 'success' = 1\n")
+	assert.NotContains(t, out, "netdata.go_plugin_collection_panics")
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+const (
+	BackoffNone        = "none"
+	BackoffLinear      = "linear"
+	BackoffExponential = "exponential"
+)
+
+const (
+	BackoffMax = 600
+
+	// backoffStep is the number of consecutive failures after which the linear delay grows.
+	backoffStep = 5
+)
+
+// Backoff defines how the delay between attempts grows with the number of consecutive failures.
+// It is used both for data collection (penalty) and auto-detection retries.
+// The zero value is the linear policy with BackoffMax.
+type Backoff struct {
+	Policy string `yaml:"policy" json:"policy"`
+	Max    int    `yaml:"max" json:"max"` // the maximum delay in seconds
+}
+
+func (b Backoff) Validate() error {
+	switch b.Policy {
+	case "", BackoffNone, BackoffLinear, BackoffExponential:
+	default:
+		return fmt.Errorf("unknown backoff policy '%s' (expected '%s', '%s' or '%s')",
+			b.Policy, BackoffNone, BackoffLinear, BackoffExponential)
+	}
+	if b.Max < 0 {
+		return fmt.Errorf("backoff max (%d) can not be negative", b.Max)
+	}
+	return nil
+}
+
+// Delay returns the delay (in seconds) before the next attempt, given the base interval and
+// the number of consecutive failures. The delay is never less than the interval.
+func (b Backoff) Delay(interval, failures int) int {
+	if interval <= 0 || failures <= 0 {
+		return interval
+	}
+
+	var delay int
+	switch b.Policy {
+	case BackoffNone:
+		return interval
+	case BackoffExponential:
+		delay = interval << min(failures, 20)
+		// jitter spreads the attempts of the jobs that failed at the same time
+		delay += rand.Intn(delay/10 + 1)
+	default:
+		delay = interval + failures/backoffStep*backoffStep*interval/2
+	}
+
+	return max(min(delay, b.maxDelay()), interval)
+}
+
+// Retries returns the number of retries, given the base interval, the policy makes within the maximum delay:
+// the sum of the delays between the retries doesn't exceed it. It is used for the jobs that have no explicit
+// auto-detection retry settings. It is never less than 1.
+func (b Backoff) Retries(interval int) int {
+	if interval <= 0 {
+		return 1
+	}
+
+	var retries, total int
+	for {
+		if total += b.Delay(interval, retries); total > b.maxDelay() {
+			break
+		}
+		retries++
+	}
+	return max(retries, 1)
+}
+
+func (b Backoff) maxDelay() int {
+	if b.Max <= 0 {
+		return BackoffMax
+	}
+	return b.Max
+}
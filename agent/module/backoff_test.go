@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff_Validate(t *testing.T) {
+	tests := map[string]struct {
+		backoff Backoff
+		wantErr bool
+	}{
+		"zero value":       {backoff: Backoff{}},
+		"none":             {backoff: Backoff{Policy: BackoffNone}},
+		"linear":           {backoff: Backoff{Policy: BackoffLinear, Max: 60}},
+		"exponential":      {backoff: Backoff{Policy: BackoffExponential, Max: 60}},
+		"unknown policy":   {backoff: Backoff{Policy: "fibonacci"}, wantErr: true},
+		"negative maximum": {backoff: Backoff{Policy: BackoffLinear, Max: -1}, wantErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if test.wantErr {
+				assert.Error(t, test.backoff.Validate())
+			} else {
+				assert.NoError(t, test.backoff.Validate())
+			}
+		})
+	}
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	tests := map[string]struct {
+		backoff  Backoff
+		interval int
+		failures int
+		wantMin  int
+		wantMax  int
+	}{
+		"no failures": {
+			backoff: Backoff{Policy: BackoffExponential}, interval: 10, failures: 0, wantMin: 10, wantMax: 10,
+		},
+		"none": {
+			backoff: Backoff{Policy: BackoffNone}, interval: 10, failures: 100, wantMin: 10, wantMax: 10,
+		},
+		"linear before the first step": {
+			backoff: Backoff{Policy: BackoffLinear}, interval: 10, failures: 4, wantMin: 10, wantMax: 10,
+		},
+		"linear after two steps": {
+			backoff: Backoff{Policy: BackoffLinear}, interval: 10, failures: 10, wantMin: 60, wantMax: 60,
+		},
+		"zero value is linear": {
+			backoff: Backoff{}, interval: 10, failures: 10, wantMin: 60, wantMax: 60,
+		},
+		"linear capped by max": {
+			backoff: Backoff{Policy: BackoffLinear, Max: 30}, interval: 10, failures: 10, wantMin: 30, wantMax: 30,
+		},
+		"linear capped by default max": {
+			backoff: Backoff{Policy: BackoffLinear}, interval: 10, failures: 1000, wantMin: BackoffMax, wantMax: BackoffMax,
+		},
+		"exponential with jitter": {
+			backoff: Backoff{Policy: BackoffExponential}, interval: 10, failures: 3, wantMin: 80, wantMax: 88,
+		},
+		"exponential capped by max": {
+			backoff: Backoff{Policy: BackoffExponential, Max: 100}, interval: 10, failures: 50, wantMin: 100, wantMax: 100,
+		},
+		"max less than interval": {
+			backoff: Backoff{Policy: BackoffExponential, Max: 5}, interval: 10, failures: 3, wantMin: 10, wantMax: 10,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				delay := test.backoff.Delay(test.interval, test.failures)
+				assert.GreaterOrEqual(t, delay, test.wantMin)
+				assert.LessOrEqual(t, delay, test.wantMax)
+			}
+		})
+	}
+}
+
+func TestBackoff_Retries(t *testing.T) {
+	tests := map[string]struct {
+		backoff  Backoff
+		interval int
+		wantMin  int
+		wantMax  int
+	}{
+		"none": {
+			backoff: Backoff{Policy: BackoffNone}, interval: 10, wantMin: 60, wantMax: 60,
+		},
+		"linear": {
+			// 5*10 + 5*35 + 5*60
+			backoff: Backoff{Policy: BackoffLinear}, interval: 10, wantMin: 15, wantMax: 15,
+		},
+		"linear with max": {
+			backoff: Backoff{Policy: BackoffLinear, Max: 60}, interval: 10, wantMin: 5, wantMax: 5,
+		},
+		"exponential with jitter": {
+			// 10 + 20 + 40 + 80 + 160 (+ jitter)
+			backoff: Backoff{Policy: BackoffExponential}, interval: 10, wantMin: 5, wantMax: 5,
+		},
+		"interval greater than max": {
+			backoff: Backoff{Policy: BackoffLinear, Max: 5}, interval: 10, wantMin: 1, wantMax: 1,
+		},
+		"zero interval": {
+			backoff: Backoff{}, interval: 0, wantMin: 1, wantMax: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				retries := test.backoff.Retries(test.interval)
+				assert.GreaterOrEqual(t, retries, test.wantMin)
+				assert.LessOrEqual(t, retries, test.wantMax)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DryRunResult is the result of Job.DryRun.
+type DryRunResult struct {
+	Charts  int
+	Metrics map[string]int64
+	Err     error
+}
+
+// DryRun runs Init, Check and one Collect without writing anything to the output.
+// It handles panic, the module is cleaned up before returning.
+func (j *Job) DryRun() (res DryRunResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			j.stats.incPanics(j.moduleName)
+			res.Err = fmt.Errorf("panic: %v", r)
+		}
+		j.module.Cleanup()
+	}()
+
+	if !j.module.Init() {
+		return DryRunResult{Err: errors.New("init failed")}
+	}
+	if !j.module.Check() {
+		return DryRunResult{Err: errors.New("check failed")}
+	}
+
+	charts := j.module.Charts()
+	if charts == nil {
+		return DryRunResult{Err: errors.New("nil charts")}
+	}
+	if err := checkCharts(*charts...); err != nil {
+		return DryRunResult{Err: fmt.Errorf("charts check: %v", err)}
+	}
+	res.Charts = len(*charts)
+
+	if res.Metrics = j.module.Collect(); len(res.Metrics) == 0 {
+		res.Err = errors.New("no metrics collected")
+	}
+	return res
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import "github.com/netdata/go.d.plugin/agent/internal/defaults"
+
+// MetricsExporter receives the latest collected values of the job charts in addition to the netdata output.
+type MetricsExporter interface {
+	// Export replaces all the previously exported values of the job.
+	Export(job string, charts []ExportedChart)
+	// Unexport removes all the exported values of the job.
+	Unexport(job string)
+}
+
+// ExportedChart is a snapshot of a chart taken after a successful data collection.
+type ExportedChart struct {
+	TypeID  string // 'type.id'
+	Context string
+	Title   string
+	Units   string
+	Labels  map[string]string
+	Dims    []ExportedDim
+}
+
+// ExportedDim is a dimension value with the multiplier and the divisor applied.
+type ExportedDim struct {
+	Name  string
+	Algo  DimAlgo
+	Value float64
+}
+
+func (j *Job) exportCharts(metrics map[string]int64) {
+	if j.exporter == nil {
+		return
+	}
+
+	charts := make([]ExportedChart, 0, len(*j.charts))
+	for _, chart := range *j.charts {
+		if chart.ignore || chart.remove || chart.Obsolete || !chart.updated {
+			continue
+		}
+
+		ec := ExportedChart{
+			TypeID:  getChartType(chart, j) + "." + getChartID(chart),
+			Context: chart.Ctx,
+			Title:   chart.Title,
+			Units:   chart.Units,
+			Labels:  make(map[string]string, len(chart.Labels)+len(j.labels)+1),
+		}
+		if ec.Context == "" {
+			ec.Context = j.moduleName + "." + chart.ID
+		}
+		for k, v := range j.labels {
+			ec.Labels[k] = v
+		}
+		for _, l := range chart.Labels {
+			if l.Key != "" {
+				ec.Labels[l.Key] = l.Value
+			}
+		}
+		ec.Labels["_collect_job"] = j.Name()
+
+		for _, dim := range chart.Dims {
+			v, ok := metrics[dim.ID]
+			if !ok || dim.remove || dim.Obsolete {
+				continue
+			}
+			ec.Dims = append(ec.Dims, ExportedDim{
+				Name:  defaults.FirstNotEmpty(dim.Name, dim.ID),
+				Algo:  dim.Algo,
+				Value: float64(v) * float64(defaults.HandleZero(dim.Mul)) / float64(defaults.HandleZero(dim.Div)),
+			})
+		}
+		if len(ec.Dims) > 0 {
+			charts = append(charts, ec)
+		}
+	}
+
+	j.exporter.Export(j.FullName(), charts)
+}
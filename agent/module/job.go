@@ -7,13 +7,16 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math/rand"
 	"os"
 	"regexp"
 	"runtime/debug"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/netdata/go.d.plugin/agent/internal/defaults"
 	"github.com/netdata/go.d.plugin/agent/netdataapi"
 	"github.com/netdata/go.d.plugin/agent/vnodes"
 	"github.com/netdata/go.d.plugin/logger"
@@ -71,15 +74,36 @@ type JobConfig struct {
 	Priority        int
 	IsStock         bool
 
+	// OutputFormat is the format of the collected data, the plugins.d protocol or netdataapi.FormatJSONL.
+	OutputFormat string
+
+	// Interval, if set, is used as the data collection interval instead of UpdateEvery.
+	// It allows sub-second intervals, the charts are still created with UpdateEvery.
+	Interval time.Duration
+	// PhaseOffset shifts the data collections of the job relative to the interval boundaries.
+	PhaseOffset time.Duration
+
+	// CollectLimiter is shared between jobs to limit the number of concurrent data collections.
+	CollectLimiter CollectLimiter
+	// TickJitter is the upper bound of a random delay applied before every data collection.
+	// It is limited to a quarter of the data collection interval.
+	TickJitter time.Duration
+	// CollectTimeout enables the watchdog that reports the job as stuck if Collect() runs longer.
+	CollectTimeout time.Duration
+	// Backoff defines how the data collection interval grows with the number of consecutive failures.
+	Backoff Backoff
+	// RuntimeStats is shared between jobs to count the skipped ticks and panics per module.
+	RuntimeStats *RuntimeStats
+	// Exporter, if set, receives the collected values in addition to the netdata output.
+	Exporter MetricsExporter
+
 	VnodeGUID     string
 	VnodeHostname string
 	VnodeLabels   map[string]string
 }
 
 const (
-	penaltyStep = 5
-	maxPenalty  = 600
-	infTries    = -1
+	infTries = -1
 )
 
 func NewJob(cfg JobConfig) *Job {
@@ -89,27 +113,41 @@ func NewJob(cfg JobConfig) *Job {
 		AutoDetectEvery: cfg.AutoDetectEvery,
 		AutoDetectTries: infTries,
 
-		pluginName:  cfg.PluginName,
-		name:        cfg.Name,
-		moduleName:  cfg.ModuleName,
-		fullName:    cfg.FullName,
-		updateEvery: cfg.UpdateEvery,
-		priority:    cfg.Priority,
-		isStock:     cfg.IsStock,
-		module:      cfg.Module,
-		labels:      cfg.Labels,
-		out:         cfg.Out,
-		runChart:    newRuntimeChart(cfg.PluginName),
-		stop:        make(chan struct{}),
-		tick:        make(chan int),
-		buf:         &buf,
-		api:         netdataapi.New(&buf),
+		pluginName:     cfg.PluginName,
+		name:           cfg.Name,
+		moduleName:     cfg.ModuleName,
+		fullName:       cfg.FullName,
+		updateEvery:    cfg.UpdateEvery,
+		priority:       cfg.Priority,
+		isStock:        cfg.IsStock,
+		limiter:        cfg.CollectLimiter,
+		jitter:         cfg.TickJitter,
+		collectTimeout: cfg.CollectTimeout,
+		backoff:        cfg.Backoff,
+		stats:          cfg.RuntimeStats,
+		exporter:       cfg.Exporter,
+		module:         cfg.Module,
+		labels:         cfg.Labels,
+		out:            cfg.Out,
+		runChart:       newRuntimeChart(cfg.PluginName),
+		stop:           make(chan struct{}),
+		quit:           make(chan struct{}),
+		done:           make(chan struct{}),
+		tick:           make(chan int),
+		buf:            &buf,
+		api:            netdataapi.NewChartAPI(cfg.OutputFormat, &buf, cfg.FullName),
 
 		vnodeGUID:     cfg.VnodeGUID,
 		vnodeHostname: cfg.VnodeHostname,
 		vnodeLabels:   cfg.VnodeLabels,
 	}
 
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = time.Duration(max(cfg.UpdateEvery, 1)) * time.Second
+	}
+	j.schedule(interval, cfg.PhaseOffset)
+
 	log := logger.New().With(
 		slog.String("collector", j.ModuleName()),
 		slog.String("job", j.Name()),
@@ -131,6 +169,9 @@ type Job struct {
 	fullName   string
 
 	updateEvery     int
+	interval        time.Duration
+	phase           int64 // scheduler ticks
+	period          int64 // scheduler ticks, the interval with the penalty applied, accessed atomically
 	AutoDetectEvery int
 	AutoDetectTries int
 	priority        int
@@ -140,6 +181,19 @@ type Job struct {
 
 	isStock bool
 
+	limiter CollectLimiter
+	jitter  time.Duration
+
+	// OnStuck is called by the watchdog when the data collection gets stuck (true) and when it recovers (false).
+	OnStuck        func(stuck bool)
+	collectTimeout time.Duration
+	collectStart   int64 // unix nano, 0 if there is no data collection in progress, accessed atomically
+	abandoned      int32 // accessed atomically
+	holdsSlot      int32 // 1 if the job holds a collect limiter slot, accessed atomically
+	quit           chan struct{}
+	done           chan struct{} // closed when the job is stopped or abandoned
+	doneOnce       sync.Once
+
 	module Module
 
 	initialized bool
@@ -150,10 +204,14 @@ type Job struct {
 	tick     chan int
 	out      io.Writer
 	buf      *bytes.Buffer
-	api      *netdataapi.API
+	api      netdataapi.ChartAPI
 
-	retries int
-	prevRun time.Time
+	backoff  Backoff
+	stats    *RuntimeStats
+	exporter MetricsExporter
+	retries  int
+	penalty  int
+	prevRun  time.Time
 
 	stop chan struct{}
 
@@ -167,32 +225,32 @@ type Job struct {
 const NetdataChartIDMaxLength = 1000
 
 // FullName returns job full name.
-func (j Job) FullName() string {
+func (j *Job) FullName() string {
 	return j.fullName
 }
 
 // ModuleName returns job module name.
-func (j Job) ModuleName() string {
+func (j *Job) ModuleName() string {
 	return j.moduleName
 }
 
 // Name returns job name.
-func (j Job) Name() string {
+func (j *Job) Name() string {
 	return j.name
 }
 
 // Panicked returns 'panicked' flag value.
-func (j Job) Panicked() bool {
+func (j *Job) Panicked() bool {
 	return j.panicked
 }
 
 // AutoDetectionEvery returns value of AutoDetectEvery.
-func (j Job) AutoDetectionEvery() int {
+func (j *Job) AutoDetectionEvery() int {
 	return j.AutoDetectEvery
 }
 
 // RetryAutoDetection returns whether it is needed to retry autodetection.
-func (j Job) RetryAutoDetection() bool {
+func (j *Job) RetryAutoDetection() bool {
 	return j.AutoDetectEvery > 0 && (j.AutoDetectTries == infTries || j.AutoDetectTries > 0)
 }
 
@@ -202,6 +260,7 @@ func (j *Job) AutoDetection() (ok bool) {
 		if r := recover(); r != nil {
 			ok = false
 			j.panicked = true
+			j.stats.incPanics(j.moduleName)
 			j.disableAutoDetection()
 
 			j.Errorf("PANIC %v", r)
@@ -243,32 +302,59 @@ func (j *Job) AutoDetection() (ok bool) {
 	return true
 }
 
-// Tick Tick.
+// Tick notifies the job about the scheduler clock (the number of SchedulerTick intervals).
+// The data collection runs if the job is due on the clock.
 func (j *Job) Tick(clock int) {
+	if !j.isDue(clock) {
+		return
+	}
 	select {
 	case j.tick <- clock:
 	default:
+		j.stats.incSkippedTicks(j.moduleName)
 		j.Debug("skip the tick due to previous run hasn't been finished")
 	}
 }
 
 // Start starts job main loop.
 func (j *Job) Start() {
-	j.Infof("started, data collection interval %ds", j.updateEvery)
+	if j.phase > 0 {
+		j.Infof("started, data collection interval %s, phase offset %s", j.interval, time.Duration(j.phase)*SchedulerTick)
+	} else {
+		j.Infof("started, data collection interval %s", j.interval)
+	}
 	defer func() { j.Info("stopped") }()
 
+	if j.collectTimeout > 0 {
+		wdStop := make(chan struct{})
+		defer close(wdStop)
+		go j.runWatchdog(wdStop)
+	}
+
 LOOP:
 	for {
 		select {
 		case <-j.stop:
 			break LOOP
-		case t := <-j.tick:
-			if t%(j.updateEvery+j.penalty()) == 0 {
-				j.runOnce()
+		case <-j.quit:
+			break LOOP
+		case <-j.tick:
+			if !j.waitToRun() {
+				break LOOP
+			}
+			j.runOnce()
+			j.releaseSlot()
+			if j.isAbandoned() {
+				break LOOP
 			}
 		}
 	}
+	j.closeDone()
 	j.module.Cleanup()
+	if j.isAbandoned() {
+		// the charts are taken over by the job that replaced this one
+		return
+	}
 	j.Cleanup()
 	j.stop <- struct{}{}
 }
@@ -280,12 +366,108 @@ func (j *Job) Stop() {
 	<-j.stop
 }
 
+// Abandon stops the job without waiting for the data collection in progress to finish.
+// The job main loop exits as soon as Collect() returns, the charts are not marked as obsolete.
+// The collect limiter slot is released immediately, so a hung data collection doesn't block other jobs.
+func (j *Job) Abandon() {
+	if atomic.CompareAndSwapInt32(&j.abandoned, 0, 1) {
+		close(j.quit)
+		j.closeDone()
+		j.releaseSlot()
+	}
+}
+
+// Done returns a channel that is closed when the job main loop exits or the job is abandoned.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+func (j *Job) closeDone() {
+	j.doneOnce.Do(func() { close(j.done) })
+}
+
+func (j *Job) isAbandoned() bool {
+	return atomic.LoadInt32(&j.abandoned) == 1
+}
+
+func (j *Job) runWatchdog(stop chan struct{}) {
+	tk := time.NewTicker(min(j.collectTimeout/2, time.Second))
+	defer tk.Stop()
+
+	var stuckStart int64
+	for {
+		select {
+		case <-stop:
+			return
+		case <-j.quit:
+			return
+		case now := <-tk.C:
+			start := atomic.LoadInt64(&j.collectStart)
+			switch {
+			case stuckStart == 0 && start != 0 && now.Sub(time.Unix(0, start)) > j.collectTimeout:
+				stuckStart = start
+				j.Errorf("data collection is stuck, running for %s (collect_timeout %s)",
+					now.Sub(time.Unix(0, start)).Round(time.Second), j.collectTimeout)
+				j.notifyStuck(true)
+			case stuckStart != 0 && start != stuckStart:
+				stuckStart = 0
+				j.Info("data collection is no longer stuck")
+				j.notifyStuck(false)
+			}
+		}
+	}
+}
+
+func (j *Job) notifyStuck(stuck bool) {
+	if j.OnStuck != nil {
+		j.OnStuck(stuck)
+	}
+}
+
+// waitToRun sleeps for a random jitter and waits for a free collection slot.
+// It returns false if the job was stopped while waiting.
+func (j *Job) waitToRun() bool {
+	if jitter := j.tickJitter(); jitter > 0 {
+		t := time.NewTimer(time.Duration(rand.Int63n(int64(jitter))))
+		select {
+		case <-j.stop:
+			t.Stop()
+			return false
+		case <-j.quit:
+			t.Stop()
+			return false
+		case <-t.C:
+		}
+	}
+	if !j.limiter.acquire(j.stop, j.quit) {
+		return false
+	}
+	atomic.StoreInt32(&j.holdsSlot, 1)
+	// the job could be abandoned while acquiring the slot
+	if j.isAbandoned() {
+		j.releaseSlot()
+		return false
+	}
+	return true
+}
+
+// releaseSlot releases the collect limiter slot if the job holds it.
+// It is called by both the main loop and Abandon, the slot is released only once.
+func (j *Job) releaseSlot() {
+	if atomic.CompareAndSwapInt32(&j.holdsSlot, 1, 0) {
+		j.limiter.release()
+	}
+}
+
 func (j *Job) disableAutoDetection() {
 	j.AutoDetectEvery = 0
 }
 
 func (j *Job) Cleanup() {
 	j.buf.Reset()
+	if j.exporter != nil {
+		j.exporter.Unexport(j.FullName())
+	}
 	if !shouldObsoleteCharts() {
 		return
 	}
@@ -311,6 +493,7 @@ func (j *Job) Cleanup() {
 		}
 	}
 
+	_ = j.api.Flush()
 	if j.buf.Len() > 0 {
 		_, _ = io.Copy(j.out, j.buf)
 	}
@@ -353,25 +536,32 @@ func (j *Job) runOnce() {
 
 	metrics := j.collect()
 
-	if j.panicked {
+	if j.panicked || j.isAbandoned() {
 		return
 	}
 
 	if j.processMetrics(metrics, curTime, sinceLastRun) {
 		j.retries = 0
+		j.exportCharts(metrics)
 	} else {
 		j.retries++
 	}
+	j.penalty = j.backoff.Delay(j.updateEvery, j.retries) - j.updateEvery
+	j.applyPenalty(j.penalty)
 
+	_ = j.api.Flush()
 	_, _ = io.Copy(j.out, j.buf)
 	j.buf.Reset()
 }
 
 func (j *Job) collect() (result map[string]int64) {
 	j.panicked = false
+	atomic.StoreInt64(&j.collectStart, time.Now().UnixNano())
 	defer func() {
+		atomic.StoreInt64(&j.collectStart, 0)
 		if r := recover(); r != nil {
 			j.panicked = true
+			j.stats.incPanics(j.moduleName)
 			j.Errorf("PANIC: %v", r)
 			if logger.Level.Enabled(slog.LevelDebug) {
 				j.Errorf("STACK: %s", debug.Stack())
@@ -487,11 +677,11 @@ func (j *Job) createChart(chart *Chart) {
 
 	for _, dim := range chart.Dims {
 		_ = j.api.DIMENSION(
-			firstNotEmpty(dim.Name, dim.ID),
+			defaults.FirstNotEmpty(dim.Name, dim.ID),
 			dim.Name,
 			dim.Algo.String(),
-			handleZero(dim.Mul),
-			handleZero(dim.Div),
+			defaults.HandleZero(dim.Mul),
+			defaults.HandleZero(dim.Div),
 			dim.DimOpts.String(),
 		)
 	}
@@ -534,9 +724,9 @@ func (j *Job) updateChart(chart *Chart, collected map[string]int64, sinceLastRun
 		chart.Dims[i] = dim
 		i++
 		if v, ok := collected[dim.ID]; !ok {
-			_ = j.api.SETEMPTY(firstNotEmpty(dim.Name, dim.ID))
+			_ = j.api.SETEMPTY(defaults.FirstNotEmpty(dim.Name, dim.ID))
 		} else {
-			_ = j.api.SET(firstNotEmpty(dim.Name, dim.ID), v)
+			_ = j.api.SET(defaults.FirstNotEmpty(dim.Name, dim.ID), v)
 			updated++
 		}
 	}
@@ -562,14 +752,6 @@ func (j *Job) updateChart(chart *Chart, collected map[string]int64, sinceLastRun
 	return chart.updated
 }
 
-func (j Job) penalty() int {
-	v := j.retries / penaltyStep * penaltyStep * j.updateEvery / 2
-	if v > maxPenalty {
-		return maxPenalty
-	}
-	return v
-}
-
 func getChartType(chart *Chart, j *Job) string {
 	if chart.typ != "" {
 		return chart.typ
@@ -614,17 +796,3 @@ func calcSinceLastRun(curTime, prevRun time.Time) int {
 func durationTo(duration time.Duration, to time.Duration) int {
 	return int(int64(duration) / (int64(to) / int64(time.Nanosecond)))
 }
-
-func firstNotEmpty(val1, val2 string) string {
-	if val1 != "" {
-		return val1
-	}
-	return val2
-}
-
-func handleZero(v int) int {
-	if v == 0 {
-		return 1
-	}
-	return v
-}
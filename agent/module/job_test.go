@@ -3,12 +3,19 @@
 package module
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/netdata/go.d.plugin/agent/netdataapi"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -243,7 +250,7 @@ func TestJob_Start(t *testing.T) {
 	job := newTestJob()
 	job.module = m
 	job.charts = job.module.Charts()
-	job.updateEvery = 1
+	job.schedule(SchedulerTick, 0)
 
 	go func() {
 		for i := 1; i < 3; i++ {
@@ -266,7 +273,7 @@ func TestJob_MainLoop_Panic(t *testing.T) {
 	}
 	job := newTestJob()
 	job.module = m
-	job.updateEvery = 1
+	job.schedule(SchedulerTick, 0)
 
 	go func() {
 		for i := 1; i < 3; i++ {
@@ -288,3 +295,344 @@ func TestJob_Tick(t *testing.T) {
 		job.Tick(i)
 	}
 }
+
+func TestJob_Start_CollectLimiter(t *testing.T) {
+	limiter := NewCollectLimiter(1)
+
+	var mux sync.Mutex
+	var inFlight, maxInFlight, collects int
+
+	var jobs []*Job
+	for i := 0; i < 3; i++ {
+		m := &MockModule{
+			ChartsFunc: func() *Charts {
+				return &Charts{&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}}}
+			},
+			CollectFunc: func() map[string]int64 {
+				mux.Lock()
+				inFlight++
+				collects++
+				maxInFlight = max(maxInFlight, inFlight)
+				mux.Unlock()
+
+				time.Sleep(time.Millisecond * 50)
+
+				mux.Lock()
+				inFlight--
+				mux.Unlock()
+				return map[string]int64{"id1": 1}
+			},
+		}
+		job := newTestJob()
+		job.module = m
+		job.charts = m.Charts()
+		job.schedule(SchedulerTick, 0)
+		job.limiter = limiter
+		job.jitter = time.Millisecond * 10
+		jobs = append(jobs, job)
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job *Job) { defer wg.Done(); job.Start() }(job)
+	}
+	time.Sleep(time.Millisecond * 50)
+
+	for _, job := range jobs {
+		job.Tick(1)
+	}
+	time.Sleep(time.Millisecond * 300)
+	for _, job := range jobs {
+		job.Stop()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, collects)
+	assert.Equal(t, 1, maxInFlight)
+}
+
+func TestJob_Stop_WhileWaitingForCollectLimiter(t *testing.T) {
+	limiter := NewCollectLimiter(1)
+	limiter <- struct{}{} // no free slots
+
+	job := newTestJob()
+	job.module = &MockModule{}
+	job.schedule(SchedulerTick, 0)
+	job.limiter = limiter
+
+	done := make(chan struct{})
+	go func() { defer close(done); job.Start() }()
+	time.Sleep(time.Millisecond * 50)
+
+	job.Tick(1)
+	time.Sleep(time.Millisecond * 50)
+	job.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job is not stopped")
+	}
+}
+
+func TestJob_Watchdog(t *testing.T) {
+	release := make(chan struct{})
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}}}
+		},
+		CollectFunc: func() map[string]int64 {
+			<-release
+			return map[string]int64{"id1": 1}
+		},
+	}
+	job := newTestJob()
+	job.module = m
+	job.charts = m.Charts()
+	job.schedule(SchedulerTick, 0)
+	job.collectTimeout = time.Millisecond * 100
+
+	events := make(chan bool, 2)
+	job.OnStuck = func(stuck bool) { events <- stuck }
+
+	done := make(chan struct{})
+	go func() { defer close(done); job.Start() }()
+	time.Sleep(time.Millisecond * 50)
+
+	job.Tick(1)
+
+	select {
+	case stuck := <-events:
+		assert.True(t, stuck)
+	case <-time.After(time.Second):
+		t.Fatal("stuck job is not reported")
+	}
+
+	close(release)
+
+	select {
+	case stuck := <-events:
+		assert.False(t, stuck)
+	case <-time.After(time.Second):
+		t.Fatal("recovered job is not reported")
+	}
+
+	job.Stop()
+	<-done
+}
+
+func TestJob_Abandon(t *testing.T) {
+	release := make(chan struct{})
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}}}
+		},
+		CollectFunc: func() map[string]int64 {
+			<-release
+			return map[string]int64{"id1": 1}
+		},
+	}
+	var buf bytes.Buffer
+	job := newTestJob()
+	job.module = m
+	job.charts = m.Charts()
+	job.schedule(SchedulerTick, 0)
+	job.out = &buf
+
+	done := make(chan struct{})
+	go func() { defer close(done); job.Start() }()
+	time.Sleep(time.Millisecond * 50)
+
+	job.Tick(1)
+	time.Sleep(time.Millisecond * 50)
+	job.Abandon()
+	close(release)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("abandoned job is not stopped")
+	}
+	assert.True(t, m.CleanupDone)
+	assert.Zero(t, buf.Len(), "abandoned job must not write to the output")
+}
+
+func TestJob_Abandon_ReleasesCollectLimiterSlot(t *testing.T) {
+	limiter := NewCollectLimiter(1)
+
+	release := make(chan struct{})
+	hung := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}}}
+		},
+		CollectFunc: func() map[string]int64 {
+			<-release
+			return map[string]int64{"id1": 1}
+		},
+	}
+	collected := make(chan struct{}, 1)
+	healthy := &MockModule{
+		ChartsFunc: hung.ChartsFunc,
+		CollectFunc: func() map[string]int64 {
+			select {
+			case collected <- struct{}{}:
+			default:
+			}
+			return map[string]int64{"id1": 1}
+		},
+	}
+
+	var jobs []*Job
+	for _, m := range []*MockModule{hung, healthy} {
+		job := newTestJob()
+		job.module = m
+		job.charts = m.Charts()
+		job.schedule(SchedulerTick, 0)
+		job.limiter = limiter
+		jobs = append(jobs, job)
+	}
+	// the watchdog reports the hung job as stuck, the jobs manager abandons it
+	jobs[0].collectTimeout = time.Millisecond * 100
+	jobs[0].OnStuck = func(stuck bool) {
+		if stuck {
+			jobs[0].Abandon()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job *Job) { defer wg.Done(); job.Start() }(job)
+	}
+	time.Sleep(time.Millisecond * 50)
+
+	jobs[0].Tick(1)
+	time.Sleep(time.Millisecond * 50)
+	jobs[1].Tick(1)
+
+	select {
+	case <-collected:
+	case <-time.After(time.Second * 2):
+		t.Fatal("the abandoned job holds the collect limiter slot")
+	}
+
+	close(release)
+	jobs[1].Stop()
+	wg.Wait()
+
+	assert.Zero(t, len(limiter), "all the slots are released once")
+}
+
+func TestJob_runOnce_Exporter(t *testing.T) {
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{
+					ID:     "id",
+					Title:  "title",
+					Units:  "units",
+					Ctx:    "module.ctx",
+					Labels: []Label{{Key: "key", Value: "value"}},
+					Dims: Dims{
+						{ID: "id1", Name: "name1", Algo: Incremental},
+						{ID: "id2", Div: 1000},
+						{ID: "id3"},
+					},
+				},
+			}
+		},
+		CollectFunc: func() map[string]int64 {
+			return map[string]int64{"id1": 1, "id2": 1500}
+		},
+	}
+	exp := &mockExporter{}
+	job := newTestJob()
+	job.module = m
+	job.charts = m.Charts()
+	job.exporter = exp
+
+	job.runOnce()
+
+	expected := []ExportedChart{
+		{
+			TypeID:  "module_job.id",
+			Context: "module.ctx",
+			Title:   "title",
+			Units:   "units",
+			Labels:  map[string]string{"key": "value", "_collect_job": jobName},
+			Dims: []ExportedDim{
+				{Name: "name1", Algo: Incremental, Value: 1},
+				{Name: "id2", Value: 1.5},
+			},
+		},
+	}
+	assert.Equal(t, expected, exp.charts[job.FullName()])
+
+	job.Cleanup()
+	assert.NotContains(t, exp.charts, job.FullName())
+}
+
+func TestJob_runOnce_JSONL(t *testing.T) {
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{
+					ID:    "id",
+					Title: "title",
+					Units: "units",
+					Ctx:   "module.ctx",
+					Dims: Dims{
+						{ID: "id1", Name: "name1", Algo: Incremental},
+						{ID: "id2", Div: 1000},
+					},
+				},
+			}
+		},
+		CollectFunc: func() map[string]int64 {
+			return map[string]int64{"id1": 1, "id2": 1500}
+		},
+	}
+	var buf bytes.Buffer
+	job := NewJob(JobConfig{
+		PluginName:   pluginName,
+		Name:         jobName,
+		ModuleName:   modName,
+		FullName:     modName + "_" + jobName,
+		Module:       m,
+		Out:          &buf,
+		OutputFormat: netdataapi.FormatJSONL,
+	})
+	job.charts = m.Charts()
+
+	job.runOnce()
+	job.runOnce()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2, "one line per data collection")
+
+	var coll netdataapi.JSONLCollection
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &coll))
+	assert.Equal(t, modName+"_"+jobName, coll.Job)
+	require.Len(t, coll.Charts, 2, "the module chart and the execution time chart")
+	assert.Equal(t, "module_job.id", coll.Charts[0].Chart)
+	assert.Equal(t, map[string]float64{"name1": 1, "id2": 1.5}, coll.Charts[0].Values)
+
+	// the charts are marked obsolete, there are no collected values
+	buf.Reset()
+	job.Cleanup()
+	assert.Zero(t, buf.Len())
+}
+
+type mockExporter struct {
+	charts map[string][]ExportedChart
+}
+
+func (e *mockExporter) Export(job string, charts []ExportedChart) {
+	if e.charts == nil {
+		e.charts = make(map[string][]ExportedChart)
+	}
+	e.charts[job] = charts
+}
+
+func (e *mockExporter) Unexport(job string) { delete(e.charts, job) }
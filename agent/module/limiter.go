@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+// CollectLimiter limits the number of concurrently running data collections across jobs.
+// A nil CollectLimiter doesn't limit anything.
+type CollectLimiter chan struct{}
+
+// NewCollectLimiter creates a CollectLimiter that allows up to n concurrent data collections.
+// It returns nil if n is not positive.
+func NewCollectLimiter(n int) CollectLimiter {
+	if n <= 0 {
+		return nil
+	}
+	return make(CollectLimiter, n)
+}
+
+// acquire blocks until a collection slot is available or stop/quit is signaled.
+// It returns false if stop or quit was signaled.
+func (l CollectLimiter) acquire(stop, quit chan struct{}) bool {
+	if l == nil {
+		return true
+	}
+	select {
+	case <-stop:
+		return false
+	case <-quit:
+		return false
+	case l <- struct{}{}:
+		return true
+	}
+}
+
+func (l CollectLimiter) release() {
+	if l == nil {
+		return
+	}
+	<-l
+}
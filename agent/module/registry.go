@@ -15,6 +15,7 @@ type Defaults struct {
 	UpdateEvery        int
 	AutoDetectionRetry int
 	Priority           int
+	Backoff            Backoff
 	Disabled           bool
 }
 
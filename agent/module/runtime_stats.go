@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"maps"
+	"sync"
+)
+
+// RuntimeStats counts the job runtime events (skipped ticks, panics) per module.
+// It is shared between jobs and is safe for concurrent use. A nil RuntimeStats counts nothing.
+type RuntimeStats struct {
+	mux          sync.Mutex
+	skippedTicks map[string]int64
+	panics       map[string]int64
+}
+
+func NewRuntimeStats() *RuntimeStats {
+	return &RuntimeStats{
+		skippedTicks: make(map[string]int64),
+		panics:       make(map[string]int64),
+	}
+}
+
+// SkippedTicks returns the number of skipped ticks per module.
+func (s *RuntimeStats) SkippedTicks() map[string]int64 {
+	if s == nil {
+		return nil
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return maps.Clone(s.skippedTicks)
+}
+
+// Panics returns the number of panics (in auto-detection and data collection) per module.
+func (s *RuntimeStats) Panics() map[string]int64 {
+	if s == nil {
+		return nil
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return maps.Clone(s.panics)
+}
+
+func (s *RuntimeStats) incSkippedTicks(moduleName string) {
+	if s == nil {
+		return
+	}
+	s.mux.Lock()
+	s.skippedTicks[moduleName]++
+	s.mux.Unlock()
+}
+
+func (s *RuntimeStats) incPanics(moduleName string) {
+	if s == nil {
+		return
+	}
+	s.mux.Lock()
+	s.panics[moduleName]++
+	s.mux.Unlock()
+}
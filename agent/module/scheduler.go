@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SchedulerTick is the resolution of the jobs scheduling. The jobs are notified every SchedulerTick with
+// the scheduler clock (see ticker.Ticker), the data collection intervals and phase offsets are rounded up to it.
+const SchedulerTick = 50 * time.Millisecond
+
+// maxJitterDivisor limits the tick jitter of a job to a part of its data collection interval (a quarter),
+// so the delayed data collection doesn't run into the next one.
+const maxJitterDivisor = 4
+
+// schedule sets the data collection interval and the phase offset (the shift relative to the interval boundaries).
+func (j *Job) schedule(interval, phase time.Duration) {
+	period := max(toTicks(interval), 1)
+	j.interval = time.Duration(period) * SchedulerTick
+	j.phase = toTicks(phase) % period
+	atomic.StoreInt64(&j.period, period)
+}
+
+// applyPenalty extends the data collection period by the penalty (seconds).
+func (j *Job) applyPenalty(penalty int) {
+	period := toTicks(j.interval) + toTicks(time.Duration(penalty)*time.Second)
+	atomic.StoreInt64(&j.period, period)
+}
+
+// tickJitter returns the upper bound of the random delay applied before the data collection.
+func (j *Job) tickJitter() time.Duration {
+	return min(j.jitter, j.interval/maxJitterDivisor)
+}
+
+// isDue reports whether the data collection should run on the clock.
+func (j *Job) isDue(clock int) bool {
+	period := atomic.LoadInt64(&j.period)
+	if period <= 0 {
+		return false
+	}
+	return (int64(clock)-j.phase)%period == 0
+}
+
+// toTicks returns the number of scheduler ticks in the duration, rounded up. It is at least 1 for positive durations.
+func toTicks(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + SchedulerTick - 1) / SchedulerTick)
+}
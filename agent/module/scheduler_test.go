@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJob_isDue(t *testing.T) {
+	tests := map[string]struct {
+		interval time.Duration
+		phase    time.Duration
+		penalty  int
+		clocks   []int
+		wantDue  []int
+	}{
+		"1s": {
+			interval: time.Second,
+			clocks:   []int{0, 1, 19, 20, 21, 40},
+			wantDue:  []int{0, 20, 40},
+		},
+		"250ms": {
+			interval: time.Millisecond * 250,
+			clocks:   []int{0, 1, 4, 5, 10, 12},
+			wantDue:  []int{0, 5, 10},
+		},
+		"interval is rounded up to the scheduler tick": {
+			interval: time.Millisecond * 120,
+			clocks:   []int{0, 2, 3, 6},
+			wantDue:  []int{0, 3, 6},
+		},
+		"phase offset": {
+			interval: time.Second,
+			phase:    time.Millisecond * 100,
+			clocks:   []int{0, 2, 20, 22},
+			wantDue:  []int{2, 22},
+		},
+		"phase offset > interval": {
+			interval: time.Millisecond * 500,
+			phase:    time.Millisecond * 600,
+			clocks:   []int{0, 2, 10, 12},
+			wantDue:  []int{2, 12},
+		},
+		"penalty": {
+			interval: time.Millisecond * 500,
+			penalty:  1,
+			clocks:   []int{0, 10, 20, 30, 40},
+			wantDue:  []int{0, 30},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			job := newTestJob()
+			job.schedule(test.interval, test.phase)
+			if test.penalty > 0 {
+				job.applyPenalty(test.penalty)
+			}
+
+			var due []int
+			for _, clock := range test.clocks {
+				if job.isDue(clock) {
+					due = append(due, clock)
+				}
+			}
+			assert.Equal(t, test.wantDue, due)
+		})
+	}
+}
+
+func TestJob_tickJitter(t *testing.T) {
+	tests := map[string]struct {
+		interval   time.Duration
+		jitter     time.Duration
+		wantJitter time.Duration
+	}{
+		"no jitter":                     {interval: time.Second, wantJitter: 0},
+		"less than a quarter":           {interval: time.Second, jitter: time.Millisecond * 200, wantJitter: time.Millisecond * 200},
+		"limited to a quarter":          {interval: time.Second, jitter: time.Millisecond * 900, wantJitter: time.Millisecond * 250},
+		"limited for sub-second jobs":   {interval: time.Millisecond * 200, jitter: time.Millisecond * 200, wantJitter: time.Millisecond * 50},
+		"not limited for long interval": {interval: time.Second * 10, jitter: time.Second * 2, wantJitter: time.Second * 2},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			job := newTestJob()
+			job.jitter = test.jitter
+			job.schedule(test.interval, 0)
+
+			assert.Equal(t, test.wantJitter, job.tickJitter())
+		})
+	}
+}
+
+func TestNewJob_Interval(t *testing.T) {
+	tests := map[string]struct {
+		cfg          JobConfig
+		wantInterval time.Duration
+	}{
+		"update every":               {cfg: JobConfig{UpdateEvery: 5}, wantInterval: time.Second * 5},
+		"update every not set":       {cfg: JobConfig{}, wantInterval: time.Second},
+		"interval over update every": {cfg: JobConfig{UpdateEvery: 1, Interval: time.Millisecond * 200}, wantInterval: time.Millisecond * 200},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.wantInterval, NewJob(test.cfg).interval)
+		})
+	}
+}
+
+func TestJob_Start_SubSecondInterval(t *testing.T) {
+	var collects int
+	m := &MockModule{
+		CollectFunc: func() map[string]int64 { collects++; return map[string]int64{"id1": 1} },
+	}
+	job := newTestJob()
+	job.module = m
+	job.charts = &Charts{{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}}}
+	job.schedule(time.Millisecond*100, 0)
+
+	go job.Start()
+	time.Sleep(time.Millisecond * 50)
+
+	for clock := 0; clock < 20; clock++ {
+		job.Tick(clock)
+		time.Sleep(time.Millisecond * 10)
+	}
+	job.Stop()
+
+	assert.Equal(t, 10, collects)
+}
+
+func TestJob_runOnce_SubSecondSinceLastRun(t *testing.T) {
+	m := &MockModule{
+		CollectFunc: func() map[string]int64 { return map[string]int64{"id1": 1} },
+	}
+	job := newTestJob()
+	job.module = m
+	job.charts = &Charts{{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}}}
+	job.schedule(time.Millisecond*200, 0)
+
+	var buf bytes.Buffer
+	job.out = &buf
+
+	job.runOnce()
+	time.Sleep(time.Millisecond * 200)
+	buf.Reset()
+	job.runOnce()
+
+	re := regexp.MustCompile(`BEGIN '` + job.FullName() + `\.id' (\d+)`)
+	match := re.FindStringSubmatch(buf.String())
+	require.Len(t, match, 2, buf.String())
+
+	us, err := strconv.Atoi(match[1])
+	require.NoError(t, err)
+	assert.InDelta(t, 200_000, us, 50_000, "BEGIN microseconds since the last run")
+}
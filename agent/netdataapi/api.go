@@ -27,6 +27,41 @@ var (
 
 func New(w io.Writer) *API { return &API{w} }
 
+// FormatJSONL is the JSON lines output format, see JSONL.
+const FormatJSONL = "jsonl"
+
+// ChartAPI is the part of the API used to create the charts and to send the collected values.
+// API writes the plugins.d protocol, JSONL writes JSON lines.
+type ChartAPI interface {
+	CHART(typeID, ID, name, title, units, family, context, chartType string,
+		priority, updateEvery int, options, plugin, module string) error
+	DIMENSION(ID, name, algorithm string, multiplier, divisor int, options string) error
+	CLABEL(key, value string, source int) error
+	CLABELCOMMIT() error
+	BEGIN(typeID, ID string, usSince int) error
+	SET(ID string, value int64) error
+	SETEMPTY(ID string) error
+	VARIABLE(ID string, value int64) error
+	END() error
+	EMPTYLINE() error
+	HOSTINFO(guid, hostname string, labels map[string]string) error
+	HOST(guid string) error
+	// Flush completes a data collection (all the charts updated at once).
+	Flush() error
+}
+
+// NewChartAPI returns the ChartAPI for the output format, the plugins.d protocol is the default.
+// The job is the full name (module_job) of the data collection job the JSON lines are written for.
+func NewChartAPI(format string, w io.Writer, job string) ChartAPI {
+	if format == FormatJSONL {
+		return NewJSONL(w, job)
+	}
+	return New(w)
+}
+
+// Flush is a no-op, the commands are written immediately.
+func (a *API) Flush() error { return nil }
+
 // CHART  creates or update a chart.
 func (a *API) CHART(
 	typeID string,
@@ -92,9 +127,10 @@ func (a *API) CLABELCOMMIT() error {
 }
 
 // BEGIN initializes data collection for a chart.
-func (a *API) BEGIN(typeID string, ID string, msSince int) (err error) {
-	if msSince > 0 {
-		_, err = a.Write([]byte("BEGIN " + "'" + typeID + "." + ID + "' " + strconv.Itoa(msSince) + "\n"))
+// usSince is the number of microseconds since the previous data collection of the chart, zero if unknown.
+func (a *API) BEGIN(typeID string, ID string, usSince int) (err error) {
+	if usSince > 0 {
+		_, err = a.Write([]byte("BEGIN " + "'" + typeID + "." + ID + "' " + strconv.Itoa(usSince) + "\n"))
 	} else {
 		_, err = a.Write([]byte("BEGIN " + "'" + typeID + "." + ID + "'\n"))
 	}
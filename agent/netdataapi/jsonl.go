@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package netdataapi
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/internal/defaults"
+)
+
+// NewJSONL returns a ChartAPI that writes every data collection of the job as one JSON object per line.
+// The calls that carry no collected values (HOST, VARIABLE, etc.) are dropped.
+func NewJSONL(w io.Writer, job string) *JSONL {
+	return &JSONL{
+		w:      w,
+		job:    job,
+		charts: make(map[string]*jsonlChart),
+		now:    time.Now,
+	}
+}
+
+// JSONLCollection is a single data collection of a job written by JSONL.
+type JSONLCollection struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Job       string            `json:"job"`
+	Charts    []JSONLChartValue `json:"charts"`
+}
+
+type JSONLChartValue struct {
+	Chart   string             `json:"chart"`
+	Context string             `json:"context"`
+	Units   string             `json:"units"`
+	Labels  map[string]string  `json:"labels,omitempty"`
+	Values  map[string]float64 `json:"values"`
+}
+
+type (
+	// JSONL implements ChartAPI, it is not safe for concurrent use (a job writes its own data collections).
+	JSONL struct {
+		w      io.Writer
+		job    string
+		charts map[string]*jsonlChart // [type.id]
+		now    func() time.Time
+
+		chart *jsonlChart      // the chart being defined (CHART) or updated (BEGIN)
+		value *JSONLChartValue // the values of the chart being updated
+		coll  *JSONLCollection // the charts updated since the last Flush
+	}
+	jsonlChart struct {
+		id      string
+		context string
+		units   string
+		labels  map[string]string
+		dims    map[string]jsonlDim // [id]
+	}
+	jsonlDim struct {
+		name string
+		mul  int
+		div  int
+	}
+)
+
+func (j *JSONL) CHART(typeID, ID, _, _, units, _, context, _ string, _, _ int, _, _, _ string) error {
+	chart := &jsonlChart{
+		id:      typeID + "." + ID,
+		units:   units,
+		context: context,
+		labels:  make(map[string]string),
+		dims:    make(map[string]jsonlDim),
+	}
+	// the chart is redefined (e.g. to add dimensions), keep the known ones
+	if prev, ok := j.charts[chart.id]; ok {
+		chart.dims = prev.dims
+		chart.labels = prev.labels
+	}
+	j.charts[chart.id] = chart
+	j.chart, j.value = chart, nil
+	return nil
+}
+
+func (j *JSONL) DIMENSION(ID, name, _ string, multiplier, divisor int, _ string) error {
+	if j.chart == nil {
+		return nil
+	}
+	j.chart.dims[ID] = jsonlDim{
+		name: defaults.FirstNotEmpty(name, ID),
+		mul:  defaults.HandleZero(multiplier),
+		div:  defaults.HandleZero(divisor),
+	}
+	return nil
+}
+
+func (j *JSONL) CLABEL(key, value string, _ int) error {
+	if j.chart != nil {
+		j.chart.labels[key] = value
+	}
+	return nil
+}
+
+func (j *JSONL) CLABELCOMMIT() error { return nil }
+
+func (j *JSONL) BEGIN(typeID, ID string, _ int) error {
+	j.chart, j.value = j.charts[typeID+"."+ID], nil
+	if j.chart == nil {
+		return nil
+	}
+	j.value = &JSONLChartValue{
+		Chart:   j.chart.id,
+		Context: j.chart.context,
+		Units:   j.chart.units,
+		Labels:  j.chart.labels,
+		Values:  make(map[string]float64),
+	}
+	return nil
+}
+
+func (j *JSONL) SET(ID string, value int64) error {
+	if j.value == nil {
+		return nil
+	}
+	dim, ok := j.chart.dims[ID]
+	if !ok {
+		dim = jsonlDim{name: ID, mul: 1, div: 1}
+	}
+	j.value.Values[dim.name] = float64(value) * float64(dim.mul) / float64(dim.div)
+	return nil
+}
+
+func (j *JSONL) SETEMPTY(string) error { return nil }
+
+func (j *JSONL) VARIABLE(string, int64) error { return nil }
+
+func (j *JSONL) END() error {
+	if j.value == nil {
+		return nil
+	}
+	if j.coll == nil {
+		j.coll = &JSONLCollection{Job: j.job}
+	}
+	j.coll.Charts = append(j.coll.Charts, *j.value)
+	j.chart, j.value = nil, nil
+	return nil
+}
+
+func (j *JSONL) EMPTYLINE() error { return nil }
+
+func (j *JSONL) HOSTINFO(string, string, map[string]string) error { return nil }
+
+func (j *JSONL) HOST(string) error { return nil }
+
+// Flush writes the charts updated since the last call as one JSON object.
+func (j *JSONL) Flush() error {
+	if j.coll == nil {
+		return nil
+	}
+	coll := j.coll
+	j.coll = nil
+	coll.Timestamp = j.now()
+
+	bs, err := json.Marshal(coll)
+	if err != nil {
+		return err
+	}
+	_, err = j.w.Write(append(bs, '\n'))
+	return err
+}
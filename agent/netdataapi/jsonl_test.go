@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package netdataapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	api := NewJSONL(&buf, "job")
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	api.now = func() time.Time { return ts }
+
+	_ = api.HOST("guid")
+	_ = api.CHART("module_job", "chart", "", "title", "units", "fam", "module.chart", "line", 1, 1, "", "go.d", "module")
+	_ = api.CLABEL("key", "value with spaces", 1)
+	_ = api.CLABELCOMMIT()
+	_ = api.DIMENSION("name1", "name1", "absolute", 1, 1, "")
+	_ = api.DIMENSION("dim2", "", "absolute", 1, 1000, "")
+	_ = api.EMPTYLINE()
+	_ = api.BEGIN("module_job", "chart", 0)
+	_ = api.SET("name1", 10)
+	_ = api.SET("dim2", 1500)
+	_ = api.SETEMPTY("dim3")
+	_ = api.VARIABLE("var", 1)
+	_ = api.END()
+	// not defined chart
+	_ = api.BEGIN("module_job", "unknown", 0)
+	_ = api.SET("dim", 1)
+	_ = api.END()
+
+	assert.Zero(t, buf.Len(), "nothing is written before Flush")
+	require.NoError(t, api.Flush())
+	require.NoError(t, api.Flush())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+
+	var got JSONLCollection
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &got))
+
+	expected := JSONLCollection{
+		Timestamp: ts,
+		Job:       "job",
+		Charts: []JSONLChartValue{
+			{
+				Chart:   "module_job.chart",
+				Context: "module.chart",
+				Units:   "units",
+				Labels:  map[string]string{"key": "value with spaces"},
+				Values:  map[string]float64{"name1": 10, "dim2": 1.5},
+			},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
+
+func TestNewChartAPI(t *testing.T) {
+	assert.IsType(t, &API{}, NewChartAPI("plugin", &bytes.Buffer{}, "job"))
+	assert.IsType(t, &JSONL{}, NewChartAPI(FormatJSONL, &bytes.Buffer{}, "job"))
+}
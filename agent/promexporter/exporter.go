@@ -0,0 +1,230 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package promexporter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/module"
+	"github.com/netdata/go.d.plugin/logger"
+)
+
+// Config is the Prometheus exporter configuration (the 'prometheus_exporter' section of the plugin config).
+type Config struct {
+	Enabled bool   `yaml:"enabled"`
+	Address string `yaml:"address"`
+	Path    string `yaml:"path"`
+}
+
+const (
+	defaultAddress = "127.0.0.1:9111"
+	defaultPath    = "/metrics"
+)
+
+func New(cfg Config) *Exporter {
+	if cfg.Address == "" {
+		cfg.Address = defaultAddress
+	}
+	if cfg.Path == "" {
+		cfg.Path = defaultPath
+	}
+	return &Exporter{
+		Logger: logger.New().With(
+			slog.String("component", "prometheus exporter"),
+		),
+		Config: cfg,
+		jobs:   make(map[string][]module.ExportedChart),
+	}
+}
+
+// Exporter keeps the latest values of the job charts and serves them in the Prometheus text format.
+// The chart context is the metric name, the dimension and the chart labels are the metric labels.
+type Exporter struct {
+	*logger.Logger
+	Config
+
+	mux  sync.RWMutex
+	jobs map[string][]module.ExportedChart // [job full name]
+}
+
+func (e *Exporter) Export(job string, charts []module.ExportedChart) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.jobs[job] = charts
+}
+
+func (e *Exporter) Unexport(job string) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	delete(e.jobs, job)
+}
+
+// Run serves the metrics until the context is canceled.
+func (e *Exporter) Run(ctx context.Context) {
+	e.Infof("instance is started, listening on '%s%s'", e.Address, e.Path)
+	defer func() { e.Info("instance is stopped") }()
+
+	mux := http.NewServeMux()
+	mux.Handle(e.Path, e)
+	srv := &http.Server{
+		Addr:              e.Address,
+		Handler:           mux,
+		ReadHeaderTimeout: time.Second * 5,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Errorf("http server: %v", err)
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+		<-done
+	case <-done:
+	}
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	e.writeMetrics(bw)
+	_ = bw.Flush()
+}
+
+type sample struct {
+	labels string
+	value  float64
+}
+
+type family struct {
+	name    string
+	typ     string
+	help    string
+	samples []sample
+}
+
+func (e *Exporter) writeMetrics(w *bufio.Writer) {
+	e.mux.RLock()
+	families := make(map[string]*family)
+	for _, charts := range e.jobs {
+		for _, chart := range charts {
+			name := metricName(chart.Context)
+			f, ok := families[name]
+			if !ok {
+				f = &family{
+					name: name,
+					typ:  metricType(chart),
+					help: chart.Title + " (" + chart.Units + ")",
+				}
+				families[name] = f
+			}
+			for _, dim := range chart.Dims {
+				f.samples = append(f.samples, sample{
+					labels: metricLabels(chart, dim),
+					value:  dim.Value,
+				})
+			}
+		}
+	}
+	e.mux.RUnlock()
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := families[name]
+		sort.Slice(f.samples, func(i, j int) bool { return f.samples[i].labels < f.samples[j].labels })
+
+		_, _ = w.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+		_, _ = w.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+		for _, s := range f.samples {
+			_, _ = w.WriteString(f.name + "{" + s.labels + "} " + strconv.FormatFloat(s.value, 'g', -1, 64) + "\n")
+		}
+	}
+}
+
+func metricType(chart module.ExportedChart) string {
+	for _, dim := range chart.Dims {
+		if dim.Algo != module.Incremental {
+			return "gauge"
+		}
+	}
+	return "counter"
+}
+
+func metricLabels(chart module.ExportedChart, dim module.ExportedDim) string {
+	labels := map[string]string{
+		"chart":     chart.TypeID,
+		"dimension": dim.Name,
+	}
+	for k, v := range chart.Labels {
+		k = labelName(k)
+		if _, ok := labels[k]; !ok {
+			labels[k] = v
+		}
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(k + `="` + escapeLabelValue(labels[k]) + `"`)
+	}
+	return sb.String()
+}
+
+var (
+	reInvalidMetricChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+	reInvalidLabelChars  = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+func metricName(ctx string) string {
+	name := reInvalidMetricChars.ReplaceAllString(ctx, "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func labelName(key string) string {
+	name := strings.TrimLeft(reInvalidLabelChars.ReplaceAllString(key, "_"), "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "label_" + name
+	}
+	return name
+}
+
+var (
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(v string) string { return labelValueReplacer.Replace(v) }
+
+func escapeHelp(v string) string { return helpReplacer.Replace(v) }
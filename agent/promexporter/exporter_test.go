@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package promexporter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/netdata/go.d.plugin/agent/module"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	exp := New(Config{Enabled: true})
+
+	assert.Equal(t, defaultAddress, exp.Address)
+	assert.Equal(t, defaultPath, exp.Path)
+}
+
+func TestExporter_ServeHTTP(t *testing.T) {
+	exp := New(Config{Enabled: true})
+
+	exp.Export("docker_network_local", []module.ExportedChart{
+		{
+			TypeID:  "docker_network_local.network_web_bytes",
+			Context: "docker_network.container_network_bytes",
+			Title:   "Container network traffic",
+			Units:   "bytes/s",
+			Labels:  map[string]string{"container_name": "web", "_collect_job": "local", "com.docker/label": `a"b`},
+			Dims: []module.ExportedDim{
+				{Name: "received", Algo: module.Incremental, Value: 100},
+				{Name: "sent", Algo: module.Incremental, Value: 50},
+			},
+		},
+		{
+			TypeID:  "docker_network_local.containers_state",
+			Context: "docker_network.containers_state",
+			Title:   "Containers by state",
+			Units:   "containers",
+			Dims: []module.ExportedDim{
+				{Name: "running", Algo: module.Absolute, Value: 2},
+			},
+		},
+	})
+	exp.Export("removed_job", []module.ExportedChart{
+		{TypeID: "removed.chart", Context: "removed.chart", Dims: []module.ExportedDim{{Name: "dim", Value: 1}}},
+	})
+	exp.Unexport("removed_job")
+
+	srv := httptest.NewServer(exp)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	bs, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	expected := `# HELP docker_network_container_network_bytes Container network traffic (bytes/s)
+# TYPE docker_network_container_network_bytes counter
+docker_network_container_network_bytes{chart="docker_network_local.network_web_bytes",collect_job="local",com_docker_label="a\"b",container_name="web",dimension="received"} 100
+docker_network_container_network_bytes{chart="docker_network_local.network_web_bytes",collect_job="local",com_docker_label="a\"b",container_name="web",dimension="sent"} 50
+# HELP docker_network_containers_state Containers by state (containers)
+# TYPE docker_network_containers_state gauge
+docker_network_containers_state{chart="docker_network_local.containers_state",dimension="running"} 2
+`
+	assert.Equal(t, expected, string(bs))
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+}
+
+func Test_metricName(t *testing.T) {
+	tests := map[string]string{
+		"docker_network.bytes":   "docker_network_bytes",
+		"netdata.go-plugin.jobs": "netdata_go_plugin_jobs",
+		"1ctx":                   "_1ctx",
+	}
+
+	for ctx, want := range tests {
+		t.Run(ctx, func(t *testing.T) {
+			assert.Equal(t, want, metricName(ctx))
+		})
+	}
+}
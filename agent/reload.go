@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package agent
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/discovery"
+	"github.com/netdata/go.d.plugin/agent/jobmgr"
+)
+
+// instance is the part of a running Agent instance that is updated on reload.
+type instance struct {
+	cfg           config
+	discoveryConf discovery.Config
+	jobsManager   *jobmgr.Manager
+	in            chan []*confgroup.Group
+	discovery     *discoveryRun
+	// staleTimeout is the time the new discovery has to re-emit the sources of the replaced one.
+	staleTimeout time.Duration
+}
+
+// discoveryRun is a running discovery manager, it is replaced on every reload.
+type discoveryRun struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	sources map[string]bool // the sources of the sent groups, it is accessed only by the forwarding goroutine
+}
+
+// startDiscovery runs the discovery manager and forwards its groups to the jobs manager.
+// The stale sources (of the replaced discovery) that are not re-emitted within the stale timeout are removed.
+func startDiscovery(ctx context.Context, mgr *discovery.Manager, in chan<- []*confgroup.Group, stale map[string]bool, staleTimeout time.Duration) *discoveryRun {
+	ctx, cancel := context.WithCancel(ctx)
+	d := &discoveryRun{cancel: cancel, done: make(chan struct{}), sources: make(map[string]bool)}
+	out := make(chan []*confgroup.Group)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); mgr.Run(ctx, out) }()
+	go func() { defer wg.Done(); forwardGroups(ctx, out, in, d.sources, stale, staleTimeout) }()
+	go func() { wg.Wait(); close(d.done) }()
+
+	return d
+}
+
+// stop stops the discovery and returns the sources it has sent, including the stale ones that are not removed yet.
+func (d *discoveryRun) stop() map[string]bool {
+	if d == nil {
+		return nil
+	}
+	d.cancel()
+	<-d.done
+	return d.sources
+}
+
+// forwardGroups sends the discovered groups and tracks their sources. The stale sources that are not
+// re-emitted within the stale timeout are sent as empty groups.
+func forwardGroups(ctx context.Context, out <-chan []*confgroup.Group, in chan<- []*confgroup.Group, sources, stale map[string]bool, staleTimeout time.Duration) {
+	defer func() {
+		for source := range stale {
+			sources[source] = true
+		}
+	}()
+
+	var staleC <-chan time.Time
+	if len(stale) > 0 {
+		tm := time.NewTimer(staleTimeout)
+		defer tm.Stop()
+		staleC = tm.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-staleC:
+			select {
+			case <-ctx.Done():
+				return
+			case in <- emptyGroups(stale):
+				stale = nil
+			}
+		case groups := <-out:
+			for _, gr := range groups {
+				delete(stale, gr.Source)
+				if len(gr.Configs) == 0 {
+					delete(sources, gr.Source)
+				} else {
+					sources[gr.Source] = true
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case in <- groups:
+			}
+		}
+	}
+}
+
+func emptyGroups(sources map[string]bool) []*confgroup.Group {
+	groups := make([]*confgroup.Group, 0, len(sources))
+	for source := range sources {
+		groups = append(groups, &confgroup.Group{Source: source})
+	}
+	return groups
+}
+
+// reload re-reads the plugin config and the modules configs and applies the changes to the running instance.
+// The discovery is restarted, the jobs manager gets the same config groups for the unchanged sources and keeps
+// the jobs whose config hash didn't change. It returns false if the changes require a full restart.
+func (a *Agent) reload(ctx context.Context, inst *instance) bool {
+	a.Info("reloading configuration")
+
+	cfg := a.loadPluginConfig()
+	a.Infof("using config: %s", cfg.String())
+
+	if option := restartRequiredBy(inst.cfg, cfg); option != "" {
+		a.Infof("'%s' is changed, the running instance needs to be restarted", option)
+		return false
+	}
+
+	a.setMaxProcs(cfg.MaxProcs)
+	inst.cfg = cfg
+
+	enabledModules := a.loadEnabledModules(cfg)
+
+	var discCfg discovery.Config
+	var discoveryManager *discovery.Manager
+	if len(enabledModules) == 0 {
+		a.Info("no modules to run")
+	} else {
+		discCfg = a.buildDiscoveryConf(enabledModules)
+		mgr, err := discovery.NewManager(discCfg)
+		if err != nil {
+			a.Errorf("%v, keeping the current modules configuration", err)
+			return true
+		}
+		discoveryManager = mgr
+	}
+
+	stale := inst.discovery.stop()
+	inst.discovery = nil
+
+	// the sources that are not read anymore (a module is disabled, a config file is added or removed)
+	// are not sent by the new discovery, send them as empty groups to stop their jobs
+	var removed []*confgroup.Group
+	for _, source := range staleSources(inst.discoveryConf, discCfg) {
+		removed = append(removed, &confgroup.Group{Source: source})
+		delete(stale, source)
+	}
+	// the other sources (service discovery targets, watched files, etc.) are known only after they are
+	// discovered, the new discovery removes the ones it doesn't re-emit in time
+	if discoveryManager == nil {
+		removed = append(removed, emptyGroups(stale)...)
+		stale = nil
+	}
+	if len(removed) > 0 {
+		select {
+		case <-ctx.Done():
+			return true
+		case inst.in <- removed:
+		}
+	}
+
+	inst.jobsManager.SetModules(enabledModules)
+
+	if discoveryManager != nil {
+		inst.discovery = startDiscovery(ctx, discoveryManager, inst.in, stale, inst.staleTimeout)
+	}
+	inst.discoveryConf = discCfg
+
+	a.Infof("configuration is reloaded: enabled modules %d, removed config sources %d", len(enabledModules), len(removed))
+	return true
+}
+
+// restartRequiredBy returns the name of the changed option that can't be applied to the running instance.
+func restartRequiredBy(prev, cur config) string {
+	switch {
+	case prev.Enabled != cur.Enabled:
+		return "enabled"
+	case prev.MaxConcurrentCollections != cur.MaxConcurrentCollections:
+		return "max_concurrent_collections"
+	case prev.CollectJitterMs != cur.CollectJitterMs:
+		return "collect_jitter_ms"
+	case prev.PrometheusExporter != cur.PrometheusExporter:
+		return "prometheus_exporter"
+	}
+	return ""
+}
+
+// staleSources returns the static config sources (read files and dummy configs) that are in prev but not in cur.
+func staleSources(prev, cur discovery.Config) []string {
+	curSources := configSources(cur)
+
+	var sources []string
+	for source := range configSources(prev) {
+		if !curSources[source] {
+			sources = append(sources, source)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+func configSources(cfg discovery.Config) map[string]bool {
+	sources := make(map[string]bool)
+	for _, path := range cfg.File.Read {
+		sources[path] = true
+	}
+	for _, name := range cfg.Dummy.Names {
+		sources[name] = true
+	}
+	return sources
+}
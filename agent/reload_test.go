@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/discovery"
+	"github.com/netdata/go.d.plugin/agent/discovery/dummy"
+	"github.com/netdata/go.d.plugin/agent/discovery/file"
+	"github.com/netdata/go.d.plugin/agent/promexporter"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgent_run_Reload(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	writeFile("test.conf", "modules:\n  module1: yes\n  module2: yes\n  module3: yes\n")
+	writeFile("module1.conf", "jobs:\n  - name: job1\n")
+	writeFile("module2.conf", "jobs:\n  - name: job1\n")
+
+	a := New(Config{
+		Name:           "test",
+		ConfDir:        []string{dir},
+		ModulesConfDir: []string{dir},
+	})
+	var mux sync.Mutex
+	stats := make(map[string]int)
+	a.ModuleRegistry = prepareRegistry(&mux, stats, "module1", "module2", "module3")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() { defer wg.Done(); a.run(ctx) }()
+
+	time.Sleep(time.Second * 3)
+
+	// module1 is disabled, module2 config is changed, module3 (default config) is not changed
+	writeFile("test.conf", "modules:\n  module1: no\n  module2: yes\n  module3: yes\n")
+	writeFile("module2.conf", "jobs:\n  - name: job1\n    update_every: 2\n")
+	trigger(a.reloadCh)
+
+	time.Sleep(time.Second * 3)
+
+	mux.Lock()
+	assert.Equal(t, 1, stats["module1_init"], "module1 init")
+	assert.Equal(t, 1, stats["module1_cleanup"], "module1 cleanup")
+	assert.Equal(t, 2, stats["module2_init"], "module2 init")
+	assert.Equal(t, 1, stats["module2_cleanup"], "module2 cleanup")
+	assert.Equal(t, 1, stats["module3_init"], "module3 init")
+	assert.Equal(t, 0, stats["module3_cleanup"], "module3 cleanup")
+	mux.Unlock()
+
+	select {
+	case <-a.restartCh:
+		t.Error("unexpected restart request")
+	default:
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+func TestRestartRequiredBy(t *testing.T) {
+	tests := map[string]struct {
+		prev, cur config
+		want      string
+	}{
+		"no changes": {
+			prev: defaultConfig(),
+			cur:  defaultConfig(),
+		},
+		"reloadable changes": {
+			prev: defaultConfig(),
+			cur:  config{Enabled: true, DefaultRun: false, MaxProcs: 2, Modules: map[string]bool{"module1": true}},
+		},
+		"enabled": {
+			prev: defaultConfig(),
+			cur:  config{Enabled: false, DefaultRun: true},
+			want: "enabled",
+		},
+		"max_concurrent_collections": {
+			prev: defaultConfig(),
+			cur:  config{Enabled: true, DefaultRun: true, MaxConcurrentCollections: 5},
+			want: "max_concurrent_collections",
+		},
+		"prometheus_exporter": {
+			prev: defaultConfig(),
+			cur:  config{Enabled: true, DefaultRun: true, PrometheusExporter: promexporter.Config{Enabled: true}},
+			want: "prometheus_exporter",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, restartRequiredBy(test.prev, test.cur))
+		})
+	}
+}
+
+func TestStaleSources(t *testing.T) {
+	prev := discovery.Config{
+		File:  file.Config{Read: []string{"/etc/module1.conf", "/etc/module2.conf"}, Watch: []string{"/etc/sd/*.conf"}},
+		Dummy: dummy.Config{Names: []string{"module3", "module4"}},
+	}
+	cur := discovery.Config{
+		File:  file.Config{Read: []string{"/etc/module2.conf", "/etc/module4.conf"}},
+		Dummy: dummy.Config{Names: []string{"module3"}},
+	}
+
+	assert.Equal(t, []string{"/etc/module1.conf", "module4"}, staleSources(prev, cur))
+	assert.Equal(t, []string{"/etc/module1.conf", "/etc/module2.conf", "module3", "module4"}, staleSources(prev, discovery.Config{}))
+}
+
+func TestForwardGroups(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan []*confgroup.Group)
+	in := make(chan []*confgroup.Group)
+	sources := make(map[string]bool)
+	// 'sd:target1' is re-emitted, 'sd:target2' is gone (e.g. a module is disabled)
+	stale := map[string]bool{"sd:target1": true, "sd:target2": true}
+
+	done := make(chan struct{})
+	go func() { defer close(done); forwardGroups(ctx, out, in, sources, stale, time.Millisecond*200) }()
+
+	groups := []*confgroup.Group{{Source: "sd:target1", Configs: []confgroup.Config{{"name": "job1"}}}}
+	out <- groups
+	assert.Equal(t, groups, <-in)
+
+	select {
+	case removed := <-in:
+		assert.Equal(t, []*confgroup.Group{{Source: "sd:target2"}}, removed)
+	case <-time.After(time.Second):
+		t.Fatal("the stale source is not removed")
+	}
+
+	out <- []*confgroup.Group{{Source: "sd:target3", Configs: []confgroup.Config{{"name": "job1"}}}}
+	<-in
+	out <- []*confgroup.Group{{Source: "sd:target3"}}
+	<-in
+
+	cancel()
+	<-done
+	assert.Equal(t, map[string]bool{"sd:target1": true}, sources)
+}
@@ -5,12 +5,14 @@ package agent
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/discovery"
 	"github.com/netdata/go.d.plugin/agent/discovery/dummy"
 	"github.com/netdata/go.d.plugin/agent/discovery/file"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd"
 	"github.com/netdata/go.d.plugin/agent/hostinfo"
 	"github.com/netdata/go.d.plugin/agent/module"
 	"github.com/netdata/go.d.plugin/agent/vnodes"
@@ -84,6 +86,7 @@ func (a *Agent) buildDiscoveryConf(enabled module.Registry) discovery.Config {
 			UpdateEvery:        creator.UpdateEvery,
 			AutoDetectionRetry: creator.AutoDetectionRetry,
 			Priority:           creator.Priority,
+			Backoff:            creator.Backoff,
 		})
 	}
 
@@ -143,6 +146,11 @@ func (a *Agent) buildDiscoveryConf(enabled module.Registry) discovery.Config {
 		}
 	}
 
+	var sdDirs []string
+	for _, dir := range a.ModulesConfDir {
+		sdDirs = append(sdDirs, filepath.Join(dir, "sd"))
+	}
+
 	a.Infof("dummy/read/watch paths: %d/%d/%d", len(dummyPaths), len(readPaths), len(a.ModulesSDConfPath))
 	return discovery.Config{
 		Registry: reg,
@@ -153,6 +161,9 @@ func (a *Agent) buildDiscoveryConf(enabled module.Registry) discovery.Config {
 		Dummy: dummy.Config{
 			Names: dummyPaths,
 		},
+		SD: sd.Config{
+			ConfDir: sdDirs,
+		},
 	}
 }
 
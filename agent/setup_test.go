@@ -28,6 +28,19 @@ func TestConfig_UnmarshalYAML(t *testing.T) {
 				},
 			},
 		},
+		"valid configuration with collection scheduling options": {
+			input: "enabled: yes\ndefault_run: yes\nmax_procs: 2\nmax_concurrent_collections: 10\ncollect_jitter_ms: 200\nmodules:\n  module1: yes",
+			wantCfg: config{
+				Enabled:                  true,
+				DefaultRun:               true,
+				MaxProcs:                 2,
+				MaxConcurrentCollections: 10,
+				CollectJitterMs:          200,
+				Modules: map[string]bool{
+					"module1": true,
+				},
+			},
+		},
 		"valid configuration with broken modules section": {
 			input: "enabled: yes\ndefault_run: yes\nmodules:\nmodule1: yes\nmodule2: yes",
 			wantCfg: config{
@@ -6,11 +6,13 @@ import "time"
 
 type (
 	// Ticker holds a channel that delivers ticks of a clock at intervals.
-	// The ticks are aligned to interval boundaries.
+	// The ticks are aligned to interval boundaries. The clock is the number of intervals
+	// since the start (UTC midnight) of the day the Ticker is created, so it is aligned to the wall clock
+	// and a dropped tick doesn't shift the following ones.
 	Ticker struct {
 		C        <-chan int
 		done     chan struct{}
-		loops    int
+		epoch    time.Time
 		interval time.Duration
 	}
 )
@@ -21,6 +23,7 @@ type (
 func New(interval time.Duration) *Ticker {
 	ticker := &Ticker{
 		interval: interval,
+		epoch:    time.Now().Truncate(time.Hour * 24),
 		done:     make(chan struct{}, 1),
 	}
 	ticker.start()
@@ -41,8 +44,7 @@ func (t *Ticker) start() {
 			case <-t.done:
 				close(ch)
 				break LOOP
-			case ch <- t.loops:
-				t.loops++
+			case ch <- int(nextRun.Sub(t.epoch) / t.interval):
 			}
 		}
 	}()
@@ -48,3 +48,23 @@ func abs(a time.Duration) time.Duration {
 	}
 	return a
 }
+
+func TestTicker_Clock(t *testing.T) {
+	interval := time.Millisecond * 100
+	tk := New(interval)
+	defer tk.Stop()
+
+	prev := <-tk.C
+	now := time.Now()
+	epoch := now.Truncate(time.Hour * 24)
+	if want := int(now.Sub(epoch).Round(interval) / interval); prev != want {
+		t.Errorf("Ticker clock is not aligned to the wall clock: expect %d but was %d", want, prev)
+	}
+	for i := 0; i < 3; i++ {
+		clock := <-tk.C
+		if clock != prev+1 {
+			t.Errorf("Ticker clock: expect %d but was %d", prev+1, clock)
+		}
+		prev = clock
+	}
+}
@@ -15,7 +15,11 @@ type Option struct {
 	ConfDir     []string `short:"c" long:"config-dir" description:"config dir to read"`
 	WatchPath   []string `short:"w" long:"watch-path" description:"config path to watch"`
 	Debug       bool     `short:"d" long:"debug" description:"debug mode"`
+	Output      string   `long:"output" description:"output format" choice:"plugin" choice:"jsonl" default:"plugin"`
+	OutputFile  string   `long:"output-file" description:"file to write the output to (default: stdout)"`
 	Version     bool     `short:"v" long:"version" description:"display the version and exit"`
+	DryRun      bool     `long:"dry-run" description:"validate the configs, run one data collection for every job and exit"`
+	Dump        string   `long:"dump" description:"print the config schema, defaults and charts of the modules and exit" choice:"json" choice:"markdown"`
 }
 
 // Parse returns parsed command-line flags in Option struct
@@ -5,6 +5,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/user"
@@ -12,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/netdata/go.d.plugin/agent"
+	"github.com/netdata/go.d.plugin/agent/safewriter"
 	"github.com/netdata/go.d.plugin/cli"
 	"github.com/netdata/go.d.plugin/logger"
 	"github.com/netdata/go.d.plugin/pkg/multipath"
@@ -107,6 +109,12 @@ func main() {
 		logger.Level.Set(slog.LevelDebug)
 	}
 
+	out, err := output(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "output: %v\n", err)
+		os.Exit(1)
+	}
+
 	a := agent.New(agent.Config{
 		Name:              name,
 		ConfDir:           confDir(opts),
@@ -117,8 +125,25 @@ func main() {
 		LockDir:           lockDir,
 		RunModule:         opts.Module,
 		MinUpdateEvery:    opts.UpdateEvery,
+		Out:               out,
+		OutputFormat:      opts.Output,
 	})
 
+	if opts.Dump != "" {
+		if err := a.Dump(os.Stdout, opts.Dump); err != nil {
+			fmt.Fprintf(os.Stderr, "dump: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.DryRun {
+		if !a.DryRun(os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	a.Debugf("plugin: name=%s, version=%s", a.Name, version)
 	if u, err := user.Current(); err == nil {
 		a.Debugf("current user: name=%s, uid=%s", u.Username, u.Uid)
@@ -130,6 +155,18 @@ func main() {
 	a.Run()
 }
 
+func output(opts *cli.Option) (io.Writer, error) {
+	if opts.OutputFile == "" {
+		return safewriter.Stdout, nil
+	}
+
+	f, err := os.OpenFile(opts.OutputFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return safewriter.New(f), nil
+}
+
 func parseCLI() *cli.Option {
 	opt, err := cli.Parse(os.Args)
 	if err != nil {
@@ -2,15 +2,67 @@ package docker_network
 
 import (
 	"fmt"
+	"github.com/docker/docker/api/types"
 	"github.com/netdata/go.d.plugin/agent/module"
-	"strings"
+	"sort"
 )
 
 const (
-	prioNetworkBytes = module.Priority + iota
+	prioHostNetworkBytes = module.Priority + iota
+	prioContainersState
+	prioContainersHealthStatus
+	prioNetworks
+
+	prioNetworkBytes
+	prioInterfacePackets
+	prioInterfaceErrors
+	prioInterfaceDropped
+
+	prioDockerNetworkContainers
+	prioDockerNetworkBytes
+)
+
+var summaryCharts = module.Charts{
+	hostNetworkBytesChart.Copy(),
+	containersStateChart.Copy(),
+	containersHealthStatusChart.Copy(),
+	networksChart.Copy(),
+}
+
+var (
+	// https://docs.docker.com/engine/api/v1.43/#tag/Container/operation/ContainerList
+	containerStates = []string{
+		"created",
+		"running",
+		"paused",
+		"restarting",
+		"removing",
+		"exited",
+		"dead",
+	}
+	containerHealthStatuses = []string{
+		"healthy",
+		"unhealthy",
+		"starting",
+		"none",
+	}
 )
 
-var summaryCharts = module.Charts{}
+var (
+	hostNetworkBytesChart = module.Chart{
+		ID:       "host_network_bytes",
+		Title:    "Total containers network bytes",
+		Units:    "bytes/s",
+		Fam:      "host",
+		Ctx:      "docker_net.host_network_bytes",
+		Priority: prioHostNetworkBytes,
+		Type:     module.Area,
+		Dims: module.Dims{
+			{ID: "host_network_bytes_rx", Name: "received", Algo: module.Incremental},
+			{ID: "host_network_bytes_tx", Name: "sent", Algo: module.Incremental},
+		},
+	}
+)
 
 var (
 	containerNetworkChartsTmpl = module.Charts{
@@ -27,19 +79,144 @@ var (
 		Priority: prioNetworkBytes,
 		Type:     module.Stacked,
 		Dims: module.Dims{
-			{ID: "container_%s_network_bytes_rx", Name: "received"},
-			{ID: "container_%s_network_bytes_tx", Name: "sent"},
+			{ID: "container_%s_network_bytes_rx", Name: "received", Algo: module.Incremental},
+			{ID: "container_%s_network_bytes_tx", Name: "sent", Algo: module.Incremental},
+		},
+	}
+)
+
+var (
+	containerInterfaceChartsTmpl = module.Charts{
+		// These will be collected for each network interface of a container
+		containerInterfacePacketsChartTmpl.Copy(),
+		containerInterfaceErrorsChartTmpl.Copy(),
+		containerInterfaceDroppedChartTmpl.Copy(),
+	}
+
+	containerInterfacePacketsChartTmpl = module.Chart{
+		ID:       "network_%s_interface_%s_packets",
+		Title:    "Network interface packets",
+		Units:    "packets/s",
+		Fam:      "interface",
+		Ctx:      "docker_net.container_interface_packets",
+		Priority: prioInterfacePackets,
+		Dims: module.Dims{
+			{ID: "container_%s_interface_%s_packets_rx", Name: "received", Algo: module.Incremental},
+			{ID: "container_%s_interface_%s_packets_tx", Name: "sent", Algo: module.Incremental},
+		},
+	}
+	containerInterfaceErrorsChartTmpl = module.Chart{
+		ID:       "network_%s_interface_%s_errors",
+		Title:    "Network interface errors",
+		Units:    "errors/s",
+		Fam:      "interface",
+		Ctx:      "docker_net.container_interface_errors",
+		Priority: prioInterfaceErrors,
+		Dims: module.Dims{
+			{ID: "container_%s_interface_%s_errors_rx", Name: "received", Algo: module.Incremental},
+			{ID: "container_%s_interface_%s_errors_tx", Name: "sent", Algo: module.Incremental},
+		},
+	}
+	containerInterfaceDroppedChartTmpl = module.Chart{
+		ID:       "network_%s_interface_%s_dropped",
+		Title:    "Network interface drops",
+		Units:    "drops/s",
+		Fam:      "interface",
+		Ctx:      "docker_net.container_interface_dropped",
+		Priority: prioInterfaceDropped,
+		Dims: module.Dims{
+			{ID: "container_%s_interface_%s_dropped_rx", Name: "received", Algo: module.Incremental},
+			{ID: "container_%s_interface_%s_dropped_tx", Name: "sent", Algo: module.Incremental},
+		},
+	}
+)
+
+var (
+	containersStateChart = module.Chart{
+		ID:       "containers_state",
+		Title:    "Total containers in a state",
+		Units:    "containers",
+		Fam:      "containers",
+		Ctx:      "docker_net.containers_state",
+		Priority: prioContainersState,
+		Type:     module.Stacked,
+		Dims: module.Dims{
+			{ID: "containers_state_created", Name: "created"},
+			{ID: "containers_state_running", Name: "running"},
+			{ID: "containers_state_paused", Name: "paused"},
+			{ID: "containers_state_restarting", Name: "restarting"},
+			{ID: "containers_state_removing", Name: "removing"},
+			{ID: "containers_state_exited", Name: "exited"},
+			{ID: "containers_state_dead", Name: "dead"},
+		},
+	}
+	containersHealthStatusChart = module.Chart{
+		ID:       "containers_health_status",
+		Title:    "Total containers by health status",
+		Units:    "containers",
+		Fam:      "containers",
+		Ctx:      "docker_net.containers_health_status",
+		Priority: prioContainersHealthStatus,
+		Type:     module.Stacked,
+		Dims: module.Dims{
+			{ID: "containers_health_status_healthy", Name: "healthy"},
+			{ID: "containers_health_status_unhealthy", Name: "unhealthy"},
+			{ID: "containers_health_status_starting", Name: "starting"},
+			{ID: "containers_health_status_none", Name: "no_healthcheck"},
+		},
+	}
+	networksChart = module.Chart{
+		ID:       "networks",
+		Title:    "Total docker networks",
+		Units:    "networks",
+		Fam:      "docker networks",
+		Ctx:      "docker_net.networks",
+		Priority: prioNetworks,
+		Dims: module.Dims{
+			{ID: "networks"},
+		},
+	}
+)
+
+var (
+	dockerNetworkChartsTmpl = module.Charts{
+		// These will be collected for each docker network (bridge, overlay, user-defined)
+		dockerNetworkContainersChartTmpl.Copy(),
+		dockerNetworkBytesChartTmpl.Copy(),
+	}
+
+	dockerNetworkContainersChartTmpl = module.Chart{
+		ID:       "docker_network_%s_containers",
+		Title:    "Docker network attached containers",
+		Units:    "containers",
+		Fam:      "docker networks",
+		Ctx:      "docker_net.docker_network_containers",
+		Priority: prioDockerNetworkContainers,
+		Dims: module.Dims{
+			{ID: "docker_network_%s_containers", Name: "attached"},
+		},
+	}
+	dockerNetworkBytesChartTmpl = module.Chart{
+		ID:       "docker_network_%s_bytes",
+		Title:    "Docker network attached containers bytes",
+		Units:    "bytes/s",
+		Fam:      "docker networks",
+		Ctx:      "docker_net.docker_network_bytes",
+		Priority: prioDockerNetworkBytes,
+		Type:     module.Area,
+		Dims: module.Dims{
+			{ID: "docker_network_%s_bytes_rx", Name: "received", Algo: module.Incremental},
+			{ID: "docker_network_%s_bytes_tx", Name: "sent", Algo: module.Incremental},
 		},
 	}
 )
 
-func (d *DockerNetwork) addContainerCharts(name string) {
+func (d *DockerNetwork) addContainerCharts(container types.Container) {
+	name := containerName(container)
 	charts := containerNetworkChartsTmpl.Copy()
 	for _, chart := range *charts {
 		chart.ID = fmt.Sprintf(chart.ID, name)
-		chart.Labels = []module.Label{
-			{Key: "container_name", Value: name},
-		}
+		chart.Labels = d.containerChartLabels(container)
 		for _, dim := range chart.Dims {
 			dim.ID = fmt.Sprintf(dim.ID, name)
 		}
@@ -50,13 +227,95 @@ func (d *DockerNetwork) addContainerCharts(name string) {
 	}
 }
 
+// containerChartLabels returns the container chart labels: the name, the image, the ID prefix
+// and the docker labels configured in 'container_labels'.
+func (d *DockerNetwork) containerChartLabels(container types.Container) []module.Label {
+	labels := []module.Label{
+		{Key: "container_name", Value: containerName(container)},
+		{Key: "image", Value: container.Image},
+		{Key: "container_id", Value: shortID(container.ID)},
+	}
+
+	keys := make([]string, 0, len(d.ContainerLabels))
+	for k := range d.ContainerLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v, ok := container.Labels[k]
+		if !ok || d.ContainerLabels[k] == "" {
+			continue
+		}
+		labels = append(labels, module.Label{Key: d.ContainerLabels[k], Value: v})
+	}
+
+	return labels
+}
+
+// removeContainerCharts removes the charts of the container and of its known interfaces.
+// The IDs are matched exactly, a prefix would also match the charts of e.g. "web_db" when "web" is gone.
 func (d *DockerNetwork) removeContainerCharts(name string) {
-	px := fmt.Sprintf("network_%s", name)
+	for _, chart := range containerNetworkChartsTmpl {
+		d.removeChart(fmt.Sprintf(chart.ID, name))
+	}
+	for iface := range d.interfaces[name] {
+		d.removeContainerInterfaceCharts(name, iface)
+	}
+}
+
+func (d *DockerNetwork) addContainerInterfaceCharts(container types.Container, iface string) {
+	name := containerName(container)
+	charts := containerInterfaceChartsTmpl.Copy()
+	for _, chart := range *charts {
+		chart.ID = fmt.Sprintf(chart.ID, name, iface)
+		chart.Labels = append(d.containerChartLabels(container),
+			module.Label{Key: "interface", Value: iface},
+		)
+		for _, dim := range chart.Dims {
+			dim.ID = fmt.Sprintf(dim.ID, name, iface)
+		}
+	}
+
+	if err := d.Charts().Add(*charts...); err != nil {
+		d.Warning(err)
+	}
+}
+
+func (d *DockerNetwork) removeContainerInterfaceCharts(name, iface string) {
+	for _, chart := range containerInterfaceChartsTmpl {
+		d.removeChart(fmt.Sprintf(chart.ID, name, iface))
+	}
+}
 
-	for _, chart := range *d.Charts() {
-		if strings.HasPrefix(chart.ID, px) {
-			chart.MarkRemove()
-			chart.MarkNotCreated()
+func (d *DockerNetwork) addDockerNetworkCharts(nw types.NetworkResource) {
+	charts := dockerNetworkChartsTmpl.Copy()
+	for _, chart := range *charts {
+		chart.ID = fmt.Sprintf(chart.ID, nw.Name)
+		chart.Labels = []module.Label{
+			{Key: "network_name", Value: nw.Name},
+			{Key: "network_driver", Value: nw.Driver},
+			{Key: "network_scope", Value: nw.Scope},
 		}
+		for _, dim := range chart.Dims {
+			dim.ID = fmt.Sprintf(dim.ID, nw.Name)
+		}
+	}
+
+	if err := d.Charts().Add(*charts...); err != nil {
+		d.Warning(err)
+	}
+}
+
+func (d *DockerNetwork) removeDockerNetworkCharts(name string) {
+	for _, chart := range dockerNetworkChartsTmpl {
+		d.removeChart(fmt.Sprintf(chart.ID, name))
+	}
+}
+
+func (d *DockerNetwork) removeChart(id string) {
+	if chart := d.Charts().Get(id); chart != nil {
+		chart.MarkRemove()
+		chart.MarkNotCreated()
 	}
 }
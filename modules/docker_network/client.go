@@ -0,0 +1,77 @@
+package docker_network
+
+import (
+	"context"
+	docker "github.com/docker/docker/client"
+	"github.com/netdata/go.d.plugin/pkg/tlscfg"
+	"net/http"
+	"strings"
+)
+
+// podmanCompatAPIVersion is the Docker API version implemented by the Podman compat API (Podman v3+).
+const podmanCompatAPIVersion = "1.40"
+
+// newDockerClient creates a client for the Docker (or Podman) API.
+// Supported addresses are 'unix://', 'tcp://', 'http://' and 'https://', TLS is used when the TLS options are set.
+func newDockerClient(cfg Config) (dockerClient, error) {
+	var opts []docker.Opt
+
+	tlsConfig, err := tlscfg.NewTLSConfig(cfg.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		// The transport has to be set before the host, WithHost configures the dialer of the current transport
+		opts = append(opts, docker.WithHTTPClient(&http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		}))
+	}
+	opts = append(opts, docker.WithHost(cfg.Address))
+
+	return docker.NewClientWithOpts(opts...)
+}
+
+func (d *DockerNetwork) negotiateAPIVersion() error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout.Duration)
+	defer cancel()
+
+	ping, err := d.client.Ping(ctx)
+	if err != nil {
+		return err
+	}
+
+	version := normalizeAPIVersion(ping.APIVersion)
+	if version != ping.APIVersion {
+		d.Debugf("API version '%s' reported by the daemon, using '%s'", ping.APIVersion, version)
+	}
+	ping.APIVersion = version
+
+	d.client.NegotiateAPIVersionPing(ping)
+
+	return nil
+}
+
+// normalizeAPIVersion converts the API version reported by the daemon to the 'major.minor' Docker API version.
+// Podman may report its own version (e.g. '4.7.2') or add a suffix (e.g. '1.41-podman'),
+// the Docker client can't compare such versions.
+func normalizeAPIVersion(version string) string {
+	if version == "" {
+		// The client will fall back to the version before the negotiation was added
+		return ""
+	}
+
+	if i := strings.IndexFunc(version, func(r rune) bool { return (r < '0' || r > '9') && r != '.' }); i != -1 {
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return podmanCompatAPIVersion
+	}
+	if parts[0] != "1" {
+		// Not a Docker API version, most likely the Podman version
+		return podmanCompatAPIVersion
+	}
+
+	return parts[0] + "." + parts[1]
+}
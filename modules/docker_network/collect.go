@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/docker/docker/api/types"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 )
 
 func (d *DockerNetwork) collect() (map[string]int64, error) {
+	// The client is long-lived, it is closed in Cleanup
 	if d.client == nil {
 		// Create a new client
 		client, err := d.newClient(d.Config)
@@ -21,19 +24,22 @@ func (d *DockerNetwork) collect() (map[string]int64, error) {
 
 	// Make sure we've negotiated the API version
 	if !d.verNegotiated {
+		if err := d.negotiateAPIVersion(); err != nil {
+			return nil, fmt.Errorf("error on negotiating API version: %v", err)
+		}
 		d.verNegotiated = true
-		d.negotiateAPIVersion()
 	}
 
-	// Defer closing the client
-	defer func() { _ = d.client.Close() }()
-
 	mx := make(map[string]int64)
 
 	// Collect our info
 	if err := d.collectContainers(mx); err != nil {
 		return nil, err
 	}
+	// The container metrics are still sent, only the per-network metrics are missing
+	if err := d.collectNetworks(mx); err != nil {
+		d.Warningf("error on collecting docker networks: %v", err)
+	}
 
 	return mx, nil
 }
@@ -45,114 +51,355 @@ func (d *DockerNetwork) collectContainers(mx map[string]int64) error {
 	defer cancel()
 
 	// Get all the containers
-	containers, err := d.client.ContainerList(ctx, types.ContainerListOptions{})
+	containers, err := d.listContainers(ctx)
 	if err != nil {
 		return err
 	}
 
+	// The summary includes all the containers, including the stopped and filtered out ones
+	d.collectContainersSummary(mx, containers)
+
+	// Filter out the containers we don't want (and the ones not running) before asking for their stats
+	containers = slices.DeleteFunc(containers, func(c types.Container) bool {
+		return !isContainerRunning(c) || !d.filter.match(c)
+	})
+
+	if d.Mode == modeNetns {
+		// Only the namespace owners are collected, the others would repeat the host or the owner traffic
+		containers = slices.DeleteFunc(containers, func(c types.Container) bool { return !ownsNetns(c) })
+		d.pids.retain(containers)
+	}
+
 	seen := make(map[string]bool)
+	seenIfaces := make(map[string]map[string]bool)
+	// The baselines of the summary byte counters, only the containers we got the stats for are kept
+	curBytes := make(map[string]netBytes)
 
-	// We need to do the stats retrieval async
-	// We need some way to know when all of them are done
-	// So we use a waitgroup
-
-	waitGroup := make(chan struct{}, len(containers))
-
-	// Loop through all the containers and get their stats
-	for _, container := range containers {
-		// Add to the waitgroup
-		waitGroup <- struct{}{}
-		// Create a new context for each container
-		ctx, cancel := context.WithTimeout(context.Background(), d.Timeout.Duration+(time.Second*5))
-		go func(ctx context.Context, container types.Container) {
-			d.Debugf("collecting stats for container %s", container.ID[:12])
-			defer func() {
-				// We're done with this container
-				<-waitGroup
-				// Close the context
-				cancel()
-			}()
-			stats, err := d.client.ContainerStats(ctx, container.ID, false)
-			if err != nil {
-				return
-			}
+	// The stats are retrieved by a bounded pool of workers, the results are aggregated here (single goroutine)
+	for _, res := range d.fetchContainersStats(containers) {
+		// We can now get the network stats
+		network := res.stats.Networks
+		// The counters are cumulative, netdata calculates the per second rate (incremental dimensions)
+		var txBytes, rxBytes uint64
+		// Loop through the networks and add em up
+		for _, net := range network {
+			txBytes += net.TxBytes
+			rxBytes += net.RxBytes
+		}
+		name := containerName(res.container)
 
-			// This returns a body that's a reader, so we need to read it
-			body := stats.Body
+		seen[name] = true
 
-			// Now we can decode the stats
-			var stat types.StatsJSON
-			if err := json.NewDecoder(body).Decode(&stat); err != nil {
-				return
-			}
-			// Close the body
-			if err := body.Close(); err != nil {
-				return
+		// The summary counters are the sums of the deltas, so a container that is gone (or we failed to get
+		// the stats for) doesn't reset them. A container that is back only gets a new baseline, so does a container
+		// with reset counters.
+		cur := netBytes{rx: rxBytes, tx: txBytes}
+		curBytes[res.container.ID] = cur
+		if prev, ok := d.prevBytes[res.container.ID]; ok && cur.rx >= prev.rx && cur.tx >= prev.tx {
+			delta := netBytes{rx: cur.rx - prev.rx, tx: cur.tx - prev.tx}
+			d.hostBytes = d.hostBytes.add(delta)
+			// A container attached to several networks is accounted only in one of them
+			if nw := primaryNetwork(res.container); nw != "" {
+				d.networkBytes[nw] = d.networkBytes[nw].add(delta)
 			}
-			// We can now get the network stats
-			network := stat.Networks
-			// If there's no previous stats for this container then we ignore it but save the stats
-			if _, ok := d.previousStats[container.ID]; !ok {
-				d.previousStats[container.ID] = stat
-				return
-			}
-			// Now we want the tx and rx bytes
-			txBytes := 0
-			rxBytes := 0
-			// Loop through the networks and add em up
-			for _, net := range network {
-				txBytes += int(net.TxBytes)
-				rxBytes += int(net.RxBytes)
+		}
+
+		if !d.containers[name] {
+			// Add the container to our charts
+			d.addContainerCharts(res.container)
+			d.containers[name] = true
+		}
+
+		// Now we create our metrics
+		px := fmt.Sprintf("container_%s_", name)
+		mx[px+"network_bytes_tx"] = int64(txBytes)
+		mx[px+"network_bytes_rx"] = int64(rxBytes)
+
+		// Per interface stats
+		for iface, net := range network {
+			if seenIfaces[name] == nil {
+				seenIfaces[name] = make(map[string]bool)
 			}
-			// Add up previous stats
-			prev := d.previousStats[container.ID]
-			for _, net := range prev.Networks {
-				txBytes -= int(net.TxBytes)
-				rxBytes -= int(net.RxBytes)
+			seenIfaces[name][iface] = true
+
+			if d.interfaces[name] == nil {
+				d.interfaces[name] = make(map[string]bool)
 			}
-			// Now we have how much traffic has happened in the last "update time" seconds
-			// So to get this into a bytes/sec we divide by the update time
-			txBytes /= d.UpdateEvery
-			rxBytes /= d.UpdateEvery
-			// Now we have what we wanted
-			name := strings.TrimPrefix(container.Names[0], "/")
-
-			seen[name] = true
-
-			if !d.containers[name] {
-				// Add the container to our charts
-				d.addContainerCharts(name)
-				d.containers[name] = true
+			if !d.interfaces[name][iface] {
+				d.addContainerInterfaceCharts(res.container, iface)
+				d.interfaces[name][iface] = true
 			}
 
-			// Now we create our metrics
-			px := fmt.Sprintf("container_%s_", name)
-			mx[px+"network_bytes_tx"] = int64(txBytes)
-			mx[px+"network_bytes_rx"] = int64(rxBytes)
-			// Update the previous stats
-			d.previousStats[container.ID] = stat
-			d.Debugf("collected stats for container %s", container.ID[:12])
-		}(ctx, container)
+			px := fmt.Sprintf("container_%s_interface_%s_", name, iface)
+			mx[px+"packets_rx"] = int64(net.RxPackets)
+			mx[px+"packets_tx"] = int64(net.TxPackets)
+			mx[px+"errors_rx"] = int64(net.RxErrors)
+			mx[px+"errors_tx"] = int64(net.TxErrors)
+			mx[px+"dropped_rx"] = int64(net.RxDropped)
+			mx[px+"dropped_tx"] = int64(net.TxDropped)
+		}
 	}
 
-	// Wait for all the containers to be done
-	for i := 0; i < cap(waitGroup); i++ {
-		waitGroup <- struct{}{}
-	}
+	d.prevBytes = curBytes
+
+	// Host-wide totals
+	mx["host_network_bytes_rx"] = int64(d.hostBytes.rx)
+	mx["host_network_bytes_tx"] = int64(d.hostBytes.tx)
 
 	for name := range d.containers {
 		if !seen[name] {
-			delete(d.containers, name)
+			// This also removes the interface charts of the container
 			d.removeContainerCharts(name)
+			delete(d.containers, name)
+			delete(d.interfaces, name)
+		}
+	}
+
+	for name, ifaces := range d.interfaces {
+		for iface := range ifaces {
+			if !seenIfaces[name][iface] {
+				delete(ifaces, iface)
+				d.removeContainerInterfaceCharts(name, iface)
+			}
 		}
 	}
 
 	return nil
 }
 
-func (d *DockerNetwork) negotiateAPIVersion() {
+func (d *DockerNetwork) collectContainersSummary(mx map[string]int64, containers []types.Container) {
+	for _, state := range containerStates {
+		mx["containers_state_"+state] = 0
+	}
+	for _, status := range containerHealthStatuses {
+		mx["containers_health_status_"+status] = 0
+	}
+
+	for _, c := range containers {
+		mx["containers_state_"+c.State]++
+		mx["containers_health_status_"+containerHealthStatus(c)]++
+	}
+}
+
+// containerHealthStatus returns the health status of the container.
+// The list of containers has no health info, it is the part of the status (e.g. 'Up 2 hours (healthy)').
+func containerHealthStatus(c types.Container) string {
+	switch {
+	case strings.HasSuffix(c.Status, "(healthy)"):
+		return "healthy"
+	case strings.HasSuffix(c.Status, "(unhealthy)"):
+		return "unhealthy"
+	case strings.HasSuffix(c.Status, "(health: starting)"):
+		return "starting"
+	default:
+		return "none"
+	}
+}
+
+// primaryNetwork returns the docker network the container traffic is accounted in: the network mode network
+// if the container is attached to it, otherwise the first (by name) attached network.
+func primaryNetwork(c types.Container) string {
+	if c.NetworkSettings == nil || len(c.NetworkSettings.Networks) == 0 {
+		return ""
+	}
+
+	mode := c.HostConfig.NetworkMode
+	if mode == "default" {
+		mode = "bridge"
+	}
+	if _, ok := c.NetworkSettings.Networks[mode]; ok {
+		return mode
+	}
+
+	var name string
+	for nw := range c.NetworkSettings.Networks {
+		if name == "" || nw < name {
+			name = nw
+		}
+	}
+	return name
+}
+
+func isContainerRunning(c types.Container) bool {
+	// Paused containers still have their network namespace and stats
+	return c.State == "running" || c.State == "paused"
+}
+
+type containerStats struct {
+	container types.Container
+	stats     types.StatsJSON
+}
+
+// fetchContainersStats gets the stats of the containers using at most 'stats_concurrency' concurrent requests.
+// The containers we failed to get the stats for are not in the result.
+func (d *DockerNetwork) fetchContainersStats(containers []types.Container) []containerStats {
+	workers := min(max(d.StatsConcurrency, 1), len(containers))
+
+	fetch := d.fetchContainerStats
+	if d.Mode == modeNetns {
+		fetch = d.fetchContainerNetDev
+	}
+
+	jobs := make(chan types.Container)
+	results := make(chan containerStats)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for container := range jobs {
+				if stats, err := fetch(container); err != nil {
+					d.Debugf("error on collecting stats for container %s: %v", shortID(container.ID), err)
+				} else {
+					results <- containerStats{container: container, stats: stats}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for _, container := range containers {
+			jobs <- container
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	var stats []containerStats
+	for res := range results {
+		stats = append(stats, res)
+	}
+
+	// Keep the order stable, so the charts are added in the same order
+	slices.SortFunc(stats, func(a, b containerStats) int {
+		return strings.Compare(containerName(a.container), containerName(b.container))
+	})
+
+	return stats
+}
+
+func (d *DockerNetwork) fetchContainerStats(container types.Container) (types.StatsJSON, error) {
+	d.Debugf("collecting stats for container %s", shortID(container.ID))
+
+	// The daemon needs some time to calculate the stats, so we give it more than the usual timeout
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout.Duration+(time.Second*5))
+	defer cancel()
+
+	resp, err := d.client.ContainerStats(ctx, container.ID, false)
+	if err != nil {
+		return types.StatsJSON{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var stats types.StatsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return types.StatsJSON{}, err
+	}
+
+	return stats, nil
+}
+
+func (d *DockerNetwork) listContainers(ctx context.Context) ([]types.Container, error) {
+	if !d.WatchEvents {
+		return d.client.ContainerList(ctx, types.ContainerListOptions{All: true})
+	}
+
+	d.watcher.start(d.client, d.Warningf)
+
+	// Containers that died are removed before asking the daemon for anything
+	for _, name := range d.watcher.takeDied() {
+		if d.containers[name] {
+			d.removeContainerCharts(name)
+			delete(d.containers, name)
+			delete(d.interfaces, name)
+		}
+	}
+	// The PIDs of the restarted containers are looked up again
+	for _, id := range d.watcher.takeRestarted() {
+		d.pids.remove(id)
+	}
+
+	now := time.Now()
+	need, gen := d.watcher.needList(d.ReconcileEvery.Duration, now)
+	if !need {
+		return d.watcher.list(), nil
+	}
+
+	containers, err := d.client.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	d.watcher.reconcile(containers, gen, now)
+
+	return containers, nil
+}
+
+func (d *DockerNetwork) collectNetworks(mx map[string]int64) error {
+	// This function will collect the docker networks and the number of the containers attached to them
+
 	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout.Duration)
 	defer cancel()
 
-	d.client.NegotiateAPIVersion(ctx)
+	// The list doesn't contain the attached containers, so we need to inspect every network
+	networks, err := d.client.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return err
+	}
+
+	mx["networks"] = int64(len(networks))
+
+	seen := make(map[string]bool)
+
+	for _, item := range networks {
+		nw, err := d.client.NetworkInspect(ctx, item.ID, types.NetworkInspectOptions{})
+		if err != nil {
+			d.Debugf("error on inspecting network %s: %v", item.Name, err)
+			continue
+		}
+
+		seen[nw.Name] = true
+
+		if !d.networks[nw.Name] {
+			d.addDockerNetworkCharts(nw)
+			d.networks[nw.Name] = true
+		}
+
+		// The bytes are summed up in collectContainers, every container is accounted only in its primary network
+		px := fmt.Sprintf("docker_network_%s_", nw.Name)
+		mx[px+"containers"] = int64(len(nw.Containers))
+		mx[px+"bytes_rx"] = int64(d.networkBytes[nw.Name].rx)
+		mx[px+"bytes_tx"] = int64(d.networkBytes[nw.Name].tx)
+	}
+
+	for name := range d.networks {
+		if !seen[name] {
+			delete(d.networks, name)
+			d.removeDockerNetworkCharts(name)
+		}
+	}
+	for name := range d.networkBytes {
+		if !seen[name] {
+			delete(d.networkBytes, name)
+		}
+	}
+
+	return nil
+}
+
+// netBytes are the received and sent bytes counters.
+type netBytes struct {
+	rx, tx uint64
+}
+
+func (b netBytes) add(other netBytes) netBytes {
+	return netBytes{rx: b.rx + other.rx, tx: b.tx + other.tx}
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
 }
@@ -4,8 +4,10 @@ import (
 	"context"
 	_ "embed"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
 	docker "github.com/docker/docker/client"
 	"github.com/netdata/go.d.plugin/agent/module"
+	"github.com/netdata/go.d.plugin/pkg/tlscfg"
 	"github.com/netdata/go.d.plugin/pkg/web"
 	"time"
 )
@@ -29,15 +31,29 @@ func New() *DockerNetwork {
 	return &DockerNetwork{
 		// This config is only overridden by the config file.
 		Config: Config{
-			Address: docker.DefaultDockerHost,
-			Timeout: web.Duration{Duration: time.Second * 5},
+			Address:          docker.DefaultDockerHost,
+			Mode:             modeAPI,
+			ProcRoot:         "/proc",
+			Timeout:          web.Duration{Duration: time.Second * 5},
+			WatchEvents:      true,
+			ReconcileEvery:   web.Duration{Duration: time.Minute},
+			StatsConcurrency: 10,
+			ContainerLabels: map[string]string{
+				"com.docker.compose.project":    "compose_project",
+				"com.docker.compose.service":    "compose_service",
+				"com.docker.swarm.service.name": "swarm_service",
+			},
 		},
-		charts: summaryCharts.Copy(),
-		newClient: func(cfg Config) (dockerClient, error) {
-			return docker.NewClientWithOpts(docker.WithHost(cfg.Address))
-		},
-		containers:    make(map[string]bool),
-		previousStats: make(map[string]types.StatsJSON),
+		charts:     summaryCharts.Copy(),
+		newClient:  newDockerClient,
+		containers: make(map[string]bool),
+		interfaces: make(map[string]map[string]bool),
+		networks:   make(map[string]bool),
+		watcher:    newContainerWatcher(),
+		pids:       newPIDCache(),
+
+		prevBytes:    make(map[string]netBytes),
+		networkBytes: make(map[string]netBytes),
 	}
 }
 
@@ -45,6 +61,25 @@ type Config struct {
 	Timeout     web.Duration `yaml:"timeout"`
 	Address     string       `yaml:"address"`
 	UpdateEvery int          `yaml:"update_every"`
+	// TLS is used for the 'tcp://' addresses of remote daemons.
+	tlscfg.TLSConfig `yaml:",inline"`
+	// Selectors use the netdata simple patterns syntax, an empty selector matches all containers.
+	ContainerSelector string `yaml:"container_selector"`
+	LabelSelector     string `yaml:"label_selector"`
+	ImageSelector     string `yaml:"image_selector"`
+	// The container set is kept up to date using the docker events stream, the containers are still listed
+	// every 'reconcile_every' and on every collection if the stream is not available.
+	WatchEvents    bool         `yaml:"watch_events"`
+	ReconcileEvery web.Duration `yaml:"reconcile_every"`
+	// The maximum number of concurrent ContainerStats requests.
+	StatsConcurrency int `yaml:"stats_concurrency"`
+	// The network stats source: 'api' (ContainerStats) or 'netns' (/proc/<pid>/net/dev of the container process).
+	// The 'netns' mode needs access to the host procfs, 'proc_root' is its mount point.
+	Mode     string `yaml:"mode"`
+	ProcRoot string `yaml:"proc_root"`
+	// Docker labels copied to the container charts labels, [docker label]chart label.
+	// An empty chart label disables copying of the docker label.
+	ContainerLabels map[string]string `yaml:"container_labels"`
 }
 
 type (
@@ -58,17 +93,36 @@ type (
 		client        dockerClient
 		verNegotiated bool
 
-		containers    map[string]bool
-		previousStats map[string]types.StatsJSON
+		filter  *containerFilter
+		watcher *containerWatcher
+		pids    *pidCache
+
+		containers map[string]bool
+		interfaces map[string]map[string]bool
+		networks   map[string]bool
+
+		// The host and docker network byte counters are built from the containers counters deltas
+		prevBytes    map[string]netBytes // [containerID]
+		hostBytes    netBytes
+		networkBytes map[string]netBytes // [docker network]
 	}
 	// For our docker client, we use the official docker client library.
 	// We embed the client in our module, so we can easily mock it in our tests.
 	dockerClient interface {
-		NegotiateAPIVersion(ctx context.Context)
+		// We negotiate the API version ourselves, Podman reports versions the client can't handle
+		Ping(ctx context.Context) (types.Ping, error)
+		NegotiateAPIVersionPing(ping types.Ping)
 		Info(ctx context.Context) (types.Info, error)
 		// We just need a list of containers and the stats about it
 		ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error)
 		ContainerStats(ctx context.Context, containerID string, stream bool) (types.ContainerStats, error)
+		// The inspect is used to find the container PID in the 'netns' mode
+		ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+		// And the networks with the containers attached to them
+		NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error)
+		NetworkInspect(ctx context.Context, networkID string, options types.NetworkInspectOptions) (types.NetworkResource, error)
+		// The events are used to track the containers lifecycle
+		Events(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error)
 		Close() error
 	}
 )
@@ -77,6 +131,18 @@ type (
 
 // Init will initialize our module.
 func (d *DockerNetwork) Init() bool {
+	if err := d.validateConfig(); err != nil {
+		d.Errorf("config validation: %v", err)
+		return false
+	}
+
+	filter, err := d.initContainerFilter()
+	if err != nil {
+		d.Errorf("init container filter: %v", err)
+		return false
+	}
+	d.filter = filter
+
 	return true
 }
 
@@ -92,8 +158,7 @@ func (d *DockerNetwork) Charts() *module.Charts {
 
 // Collect will collect the metrics from the docker client.
 func (d *DockerNetwork) Collect() map[string]int64 {
-	// All we'll be collecting is the current bit rate of the network interface.
-	// This does mean we need to store a previous value, so we can calculate the difference.
+	// We collect the cumulative network counters, the rate is calculated by netdata (incremental dimensions).
 	mx, err := d.collect()
 	if err != nil {
 		d.Error(err)
@@ -107,6 +172,7 @@ func (d *DockerNetwork) Collect() map[string]int64 {
 
 // Cleanup will close our docker client.s
 func (d *DockerNetwork) Cleanup() {
+	d.watcher.stop()
 	if d.client == nil {
 		return
 	}
@@ -1 +1,983 @@
 package docker_network
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/network"
+	"github.com/netdata/go.d.plugin/agent/module"
+	"github.com/netdata/go.d.plugin/pkg/tlscfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestNew(t *testing.T) {
+	assert.IsType(t, (*DockerNetwork)(nil), New())
+}
+
+func TestDockerNetwork_Init(t *testing.T) {
+	tests := map[string]struct {
+		config   Config
+		wantFail bool
+	}{
+		"default": {
+			config: New().Config,
+		},
+		"valid selectors": {
+			config: Config{
+				ContainerSelector: "web* !*",
+				LabelSelector:     "com.docker.compose.project=shop",
+				ImageSelector:     "nginx:*",
+			},
+		},
+		"invalid container selector": {
+			wantFail: true,
+			config:   Config{ContainerSelector: "[]"},
+		},
+		"invalid label selector": {
+			wantFail: true,
+			config:   Config{LabelSelector: "[]"},
+		},
+		"netns mode": {
+			config: Config{Mode: modeNetns, ProcRoot: "/host/proc"},
+		},
+		"netns mode without proc root": {
+			wantFail: true,
+			config:   Config{Mode: modeNetns},
+		},
+		"unknown mode": {
+			wantFail: true,
+			config:   Config{Mode: "cgroups"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := New()
+			d.Config = test.config
+
+			if test.wantFail {
+				assert.False(t, d.Init())
+			} else {
+				assert.True(t, d.Init())
+			}
+		})
+	}
+}
+
+func TestDockerNetwork_Charts(t *testing.T) {
+	assert.Equal(t, len(summaryCharts), len(*New().Charts()))
+}
+
+func TestDockerNetwork_Cleanup(t *testing.T) {
+	d := New()
+	client := prepareMockClient()
+	d.client = client
+
+	d.Cleanup()
+
+	assert.Nil(t, d.client)
+	assert.True(t, client.closeCalled)
+}
+
+func TestDockerNetwork_Collect(t *testing.T) {
+	tests := map[string]struct {
+		prepare       func() *mockClient
+		wantMetrics   map[string]int64
+		wantNumCharts int
+	}{
+		"one container with one interface": {
+			prepare: prepareMockClient,
+			wantMetrics: map[string]int64{
+				"container_web_network_bytes_rx":          1000,
+				"container_web_network_bytes_tx":          500,
+				"container_web_interface_eth0_packets_rx": 10,
+				"container_web_interface_eth0_packets_tx": 4,
+				"container_web_interface_eth0_errors_rx":  1,
+				"container_web_interface_eth0_errors_tx":  0,
+				"container_web_interface_eth0_dropped_rx": 3,
+				"container_web_interface_eth0_dropped_tx": 2,
+				"host_network_bytes_rx":                   0,
+				"host_network_bytes_tx":                   0,
+				"docker_network_bridge_containers":        1,
+				"docker_network_bridge_bytes_rx":          0,
+				"docker_network_bridge_bytes_tx":          0,
+				"docker_network_host_containers":          0,
+				"docker_network_host_bytes_rx":            0,
+				"docker_network_host_bytes_tx":            0,
+				"containers_state_created":                0,
+				"containers_state_running":                1,
+				"containers_state_paused":                 0,
+				"containers_state_restarting":             0,
+				"containers_state_removing":               0,
+				"containers_state_exited":                 1,
+				"containers_state_dead":                   0,
+				"containers_health_status_healthy":        1,
+				"containers_health_status_unhealthy":      0,
+				"containers_health_status_starting":       0,
+				"containers_health_status_none":           1,
+				"networks":                                2,
+			},
+			wantNumCharts: len(summaryCharts) +
+				len(containerNetworkChartsTmpl) +
+				len(containerInterfaceChartsTmpl) +
+				len(dockerNetworkChartsTmpl)*2,
+		},
+		"error on container list": {
+			prepare: func() *mockClient {
+				m := prepareMockClient()
+				m.errOnContainerList = true
+				return m
+			},
+			wantMetrics:   nil,
+			wantNumCharts: len(summaryCharts),
+		},
+		"error on network list": {
+			prepare: func() *mockClient {
+				m := prepareMockClient()
+				m.errOnNetworkList = true
+				return m
+			},
+			wantMetrics: map[string]int64{
+				"container_web_network_bytes_rx":          1000,
+				"container_web_network_bytes_tx":          500,
+				"container_web_interface_eth0_packets_rx": 10,
+				"container_web_interface_eth0_packets_tx": 4,
+				"container_web_interface_eth0_errors_rx":  1,
+				"container_web_interface_eth0_errors_tx":  0,
+				"container_web_interface_eth0_dropped_rx": 3,
+				"container_web_interface_eth0_dropped_tx": 2,
+				"host_network_bytes_rx":                   0,
+				"host_network_bytes_tx":                   0,
+				"containers_state_created":                0,
+				"containers_state_running":                1,
+				"containers_state_paused":                 0,
+				"containers_state_restarting":             0,
+				"containers_state_removing":               0,
+				"containers_state_exited":                 1,
+				"containers_state_dead":                   0,
+				"containers_health_status_healthy":        1,
+				"containers_health_status_unhealthy":      0,
+				"containers_health_status_starting":       0,
+				"containers_health_status_none":           1,
+			},
+			wantNumCharts: len(summaryCharts) +
+				len(containerNetworkChartsTmpl) +
+				len(containerInterfaceChartsTmpl),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := New()
+			defer d.Cleanup()
+			client := test.prepare()
+			d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+			require.True(t, d.Init())
+
+			mx := d.Collect()
+
+			assert.Equal(t, test.wantMetrics, mx)
+			assert.Equal(t, test.wantNumCharts, len(*d.Charts()))
+			for _, chart := range *d.Charts() {
+				if !summaryCharts.Has(chart.ID) {
+					assert.NotEmpty(t, chart.Labels, chart.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestDockerNetwork_Collect_RemovesGoneInterfaceCharts(t *testing.T) {
+	d := New()
+	defer d.Cleanup()
+	client := prepareMockClient()
+	d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+	require.True(t, d.Init())
+
+	_ = d.Collect()
+	require.Len(t, *d.Charts(), len(summaryCharts)+
+		len(containerNetworkChartsTmpl)+
+		len(containerInterfaceChartsTmpl)+
+		len(dockerNetworkChartsTmpl)*2)
+
+	delete(client.networks, "eth0")
+	_ = d.Collect()
+
+	for _, chart := range *d.Charts() {
+		if strings.HasPrefix(chart.ID, "network_web_interface_eth0_") {
+			assert.True(t, chart.Obsolete, chart.ID)
+		} else {
+			assert.False(t, chart.Obsolete, chart.ID)
+		}
+	}
+}
+
+func TestDockerNetwork_Collect_RemovesGoneContainerCharts(t *testing.T) {
+	d := New()
+	defer d.Cleanup()
+	d.WatchEvents = false
+	client := prepareMockClient()
+	d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+	require.True(t, d.Init())
+
+	_ = d.Collect()
+	require.Len(t, d.containers, 1)
+
+	client.containers = nil
+	_ = d.Collect()
+
+	assert.Empty(t, d.containers)
+	assert.Empty(t, d.interfaces)
+	for _, chart := range *d.Charts() {
+		if strings.HasPrefix(chart.ID, "network_web_") {
+			assert.True(t, chart.Obsolete, chart.ID)
+		} else {
+			assert.False(t, chart.Obsolete, chart.ID)
+		}
+	}
+}
+
+func TestDockerNetwork_Collect_RemovesOnlyGoneContainerCharts(t *testing.T) {
+	d := New()
+	defer d.Cleanup()
+	d.WatchEvents = false
+	client := prepareMockClient()
+	client.containers = append(client.containers, types.Container{
+		ID: "abcdef1234567890", Names: []string{"/web_db"}, State: "running", Status: "Up 2 hours",
+	})
+	d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+	require.True(t, d.Init())
+
+	_ = d.Collect()
+	require.Len(t, d.containers, 2)
+
+	client.containers = client.containers[1:]
+	_ = d.Collect()
+
+	require.Len(t, d.containers, 1)
+	for _, chart := range *d.Charts() {
+		if strings.HasPrefix(chart.ID, "network_web_db_") {
+			assert.False(t, chart.Obsolete, chart.ID)
+		} else if strings.HasPrefix(chart.ID, "network_web_") {
+			assert.True(t, chart.Obsolete, chart.ID)
+		}
+	}
+}
+
+func TestDockerNetwork_Collect_RemovesOnlyGoneDockerNetworkCharts(t *testing.T) {
+	d := New()
+	defer d.Cleanup()
+	client := prepareMockClient()
+	client.dockerNetworks = append(client.dockerNetworks,
+		types.NetworkResource{ID: "myapp_id", Name: "myapp", Driver: "bridge", Scope: "local"},
+		types.NetworkResource{ID: "myapp_default_id", Name: "myapp_default", Driver: "bridge", Scope: "local"},
+	)
+	d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+	require.True(t, d.Init())
+
+	_ = d.Collect()
+	require.Len(t, d.networks, 4)
+
+	client.dockerNetworks = slices.DeleteFunc(client.dockerNetworks, func(nw types.NetworkResource) bool {
+		return nw.Name == "myapp"
+	})
+	_ = d.Collect()
+
+	require.Len(t, d.networks, 3)
+	for _, chart := range *d.Charts() {
+		if strings.HasPrefix(chart.ID, "docker_network_myapp_default_") {
+			assert.False(t, chart.Obsolete, chart.ID)
+		} else if strings.HasPrefix(chart.ID, "docker_network_myapp_") {
+			assert.True(t, chart.Obsolete, chart.ID)
+		}
+	}
+}
+
+func TestContainerFilter_Match(t *testing.T) {
+	containers := []types.Container{
+		{ID: "1", Names: []string{"/web"}, Image: "nginx:latest", Labels: map[string]string{
+			"com.docker.compose.project": "shop", "com.docker.compose.service": "web",
+		}},
+		{ID: "2", Names: []string{"/api"}, Image: "shop/api:1.0", Labels: map[string]string{
+			"com.docker.compose.project": "shop", "com.docker.compose.service": "api",
+		}},
+		{ID: "3", Names: []string{"/db"}, Image: "postgres:16"},
+	}
+
+	tests := map[string]struct {
+		config         Config
+		wantContainers []string
+	}{
+		"no selectors": {
+			wantContainers: []string{"web", "api", "db"},
+		},
+		"container selector": {
+			config:         Config{ContainerSelector: "!db *"},
+			wantContainers: []string{"web", "api"},
+		},
+		"image selector": {
+			config:         Config{ImageSelector: "nginx*"},
+			wantContainers: []string{"web"},
+		},
+		"label selector": {
+			config:         Config{LabelSelector: "com.docker.compose.service=api"},
+			wantContainers: []string{"api"},
+		},
+		"all selectors": {
+			config: Config{
+				ContainerSelector: "*",
+				ImageSelector:     "!postgres* *",
+				LabelSelector:     "com.docker.compose.project=shop",
+			},
+			wantContainers: []string{"web", "api"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := New()
+			d.Config = test.config
+
+			f, err := d.initContainerFilter()
+			require.NoError(t, err)
+
+			var matched []string
+			for _, c := range containers {
+				if f.match(c) {
+					matched = append(matched, containerName(c))
+				}
+			}
+			assert.Equal(t, test.wantContainers, matched)
+		})
+	}
+}
+
+func TestDockerNetwork_Collect_SkipsFilteredContainers(t *testing.T) {
+	d := New()
+	defer d.Cleanup()
+	d.ContainerSelector = "!web *"
+	client := prepareMockClient()
+	d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+	require.True(t, d.Init())
+	_ = d.Collect()
+
+	assert.Empty(t, d.containers)
+	assert.Empty(t, client.statsCalls)
+}
+
+func TestDockerNetwork_Collect_StatsConcurrency(t *testing.T) {
+	d := New()
+	defer d.Cleanup()
+	d.StatsConcurrency = 3
+	client := prepareMockClient()
+	client.statsDelay = time.Millisecond * 10
+	client.containers = nil
+	for i := 0; i < 20; i++ {
+		client.containers = append(client.containers, types.Container{
+			ID:    fmt.Sprintf("%016d", i),
+			Names: []string{fmt.Sprintf("/container%d", i)},
+			State: "running",
+		})
+	}
+	d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+	require.True(t, d.Init())
+
+	_ = d.Collect()
+	client.addBytes(100, 50)
+	mx := d.Collect()
+
+	assert.Len(t, client.statsCalls, 40)
+	assert.Len(t, d.containers, 20)
+	assert.LessOrEqual(t, client.maxInFlight, 3)
+	assert.Equal(t, int64(20*100), mx["host_network_bytes_rx"])
+	assert.Equal(t, int64(20*50), mx["host_network_bytes_tx"])
+	assert.False(t, client.closeCalled)
+}
+
+func TestDockerNetwork_Collect_SummaryBytes(t *testing.T) {
+	d := New()
+	defer d.Cleanup()
+	client := prepareMockClient()
+	api := types.Container{ID: "abcdef1234567890", Names: []string{"/api"}, State: "running"}
+	api.HostConfig.NetworkMode = "app"
+	api.NetworkSettings = &types.SummaryNetworkSettings{Networks: map[string]*network.EndpointSettings{
+		"app":    {},
+		"bridge": {},
+	}}
+	client.containers = append(client.containers, api)
+	client.dockerNetworks[0].Containers["abcdef1234567890"] = types.EndpointResource{Name: "api"}
+	client.dockerNetworks = append(client.dockerNetworks, types.NetworkResource{
+		ID: "app_id", Name: "app", Driver: "bridge", Scope: "local",
+		Containers: map[string]types.EndpointResource{
+			"abcdef1234567890": {Name: "api"},
+		},
+	})
+	d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+	require.True(t, d.Init())
+
+	summary := func(mx map[string]int64) map[string]int64 {
+		return map[string]int64{
+			"host":   mx["host_network_bytes_rx"],
+			"bridge": mx["docker_network_bridge_bytes_rx"],
+			"app":    mx["docker_network_app_bytes_rx"],
+		}
+	}
+
+	// the first collection sets the baselines
+	assert.Equal(t, map[string]int64{"host": 0, "bridge": 0, "app": 0}, summary(d.Collect()))
+
+	// 'api' is attached to both networks, it is accounted only in its network mode network
+	client.addBytes(100, 50)
+	assert.Equal(t, map[string]int64{"host": 200, "bridge": 100, "app": 100}, summary(d.Collect()))
+
+	// failed to get the 'api' stats, the totals are not reset
+	client.statsErrors = map[string]bool{"abcdef1234567890": true}
+	client.addBytes(100, 50)
+	assert.Equal(t, map[string]int64{"host": 300, "bridge": 200, "app": 100}, summary(d.Collect()))
+
+	// 'api' is back, its counters are the new baseline (no spike)
+	client.statsErrors = nil
+	client.addBytes(100, 50)
+	assert.Equal(t, map[string]int64{"host": 400, "bridge": 300, "app": 100}, summary(d.Collect()))
+
+	client.addBytes(100, 50)
+	assert.Equal(t, map[string]int64{"host": 600, "bridge": 400, "app": 200}, summary(d.Collect()))
+}
+
+func TestDockerNetwork_Collect_Events(t *testing.T) {
+	d := New()
+	defer d.Cleanup()
+	client := prepareMockClient()
+	d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+	require.True(t, d.Init())
+
+	_ = d.Collect()
+	require.Equal(t, 1, client.eventsCalls)
+	require.Equal(t, 1, client.listCalls)
+	require.True(t, d.containers["web"])
+
+	// no events, the container set is up to date
+	_ = d.Collect()
+	assert.Equal(t, 1, client.listCalls)
+
+	// the container died, its charts are removed without listing the containers
+	client.sendEvent(t, d, events.Message{Type: events.ContainerEventType, Action: "die", Actor: events.Actor{ID: "1234567890abcdef"}})
+	_ = d.Collect()
+	assert.Equal(t, 1, client.listCalls)
+	assert.False(t, d.containers["web"])
+	for _, chart := range *d.Charts() {
+		if strings.HasPrefix(chart.ID, "network_web_") {
+			assert.True(t, chart.Obsolete, chart.ID)
+		}
+	}
+
+	// a new container started, the containers are listed
+	client.sendEvent(t, d, events.Message{Type: events.ContainerEventType, Action: "start", Actor: events.Actor{ID: "1234567890abcdef"}})
+	_ = d.Collect()
+	assert.Equal(t, 2, client.listCalls)
+	assert.True(t, d.containers["web"])
+}
+
+func TestDockerNetwork_Collect_EventsFallbackToPolling(t *testing.T) {
+	tests := map[string]struct {
+		prepare         func(d *DockerNetwork, m *mockClient)
+		wantEventsCalls int
+	}{
+		"events stream error": {
+			prepare:         func(_ *DockerNetwork, m *mockClient) { m.errOnEvents = true },
+			wantEventsCalls: 3,
+		},
+		"events disabled": {
+			prepare:         func(d *DockerNetwork, _ *mockClient) { d.WatchEvents = false },
+			wantEventsCalls: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := New()
+			defer d.Cleanup()
+			client := prepareMockClient()
+			test.prepare(d, client)
+			d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+			require.True(t, d.Init())
+
+			for i := 0; i < 3; i++ {
+				_ = d.Collect()
+				if client.errOnEvents {
+					// wait for the subscription to fail
+					require.Eventually(t, func() bool {
+						d.watcher.mux.Lock()
+						defer d.watcher.mux.Unlock()
+						return !d.watcher.running
+					}, time.Second, time.Millisecond*10)
+				}
+			}
+
+			assert.Equal(t, 3, client.listCalls)
+			assert.Equal(t, test.wantEventsCalls, client.eventsCalls)
+		})
+	}
+}
+
+func TestDockerNetwork_Collect_NegotiatesAPIVersion(t *testing.T) {
+	tests := map[string]struct {
+		apiVersion  string
+		errOnPing   bool
+		wantVersion string
+		wantFail    bool
+	}{
+		"docker":                {apiVersion: "1.43", wantVersion: "1.43"},
+		"podman compat":         {apiVersion: "1.41", wantVersion: "1.41"},
+		"podman version suffix": {apiVersion: "1.41-podman", wantVersion: "1.41"},
+		"podman libpod version": {apiVersion: "4.7.2", wantVersion: podmanCompatAPIVersion},
+		"no version":            {apiVersion: "", wantVersion: ""},
+		"error on ping":         {errOnPing: true, wantFail: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := New()
+			defer d.Cleanup()
+			client := prepareMockClient()
+			client.apiVersion = test.apiVersion
+			client.errOnPing = test.errOnPing
+			d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+			require.True(t, d.Init())
+
+			mx := d.Collect()
+
+			if test.wantFail {
+				assert.Nil(t, mx)
+				assert.False(t, d.verNegotiated)
+			} else {
+				assert.NotNil(t, mx)
+				assert.True(t, d.verNegotiated)
+				assert.Equal(t, test.wantVersion, client.negotiatedVersion)
+			}
+		})
+	}
+}
+
+func TestNewDockerClient(t *testing.T) {
+	tests := map[string]struct {
+		config   Config
+		wantFail bool
+	}{
+		"unix socket":   {config: Config{Address: "unix:///var/run/docker.sock"}},
+		"podman socket": {config: Config{Address: "unix:///run/podman/podman.sock"}},
+		"tcp":           {config: Config{Address: "tcp://127.0.0.1:2375"}},
+		"tcp with tls": {config: Config{
+			Address:   "tcp://127.0.0.1:2376",
+			TLSConfig: tlscfg.TLSConfig{InsecureSkipVerify: true},
+		}},
+		"invalid address": {wantFail: true, config: Config{Address: "127.0.0.1"}},
+		"missing tls ca": {wantFail: true, config: Config{
+			Address:   "tcp://127.0.0.1:2376",
+			TLSConfig: tlscfg.TLSConfig{TLSCA: "testdata/not_exists.pem"},
+		}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client, err := newDockerClient(test.config)
+
+			if test.wantFail {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.NoError(t, client.Close())
+			}
+		})
+	}
+}
+
+func TestDockerNetwork_Collect_ContainerChartLabels(t *testing.T) {
+	tests := map[string]struct {
+		config     string
+		wantLabels []module.Label
+	}{
+		"default": {
+			wantLabels: []module.Label{
+				{Key: "container_name", Value: "web"},
+				{Key: "image", Value: "nginx:latest"},
+				{Key: "container_id", Value: "1234567890ab"},
+				{Key: "compose_project", Value: "shop"},
+				{Key: "compose_service", Value: "web"},
+			},
+		},
+		"custom labels": {
+			config: `
+container_labels:
+  com.docker.compose.service: ""
+  com.example.team: team
+`,
+			wantLabels: []module.Label{
+				{Key: "container_name", Value: "web"},
+				{Key: "image", Value: "nginx:latest"},
+				{Key: "container_id", Value: "1234567890ab"},
+				{Key: "compose_project", Value: "shop"},
+				{Key: "team", Value: "payments"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := New()
+			defer d.Cleanup()
+			require.NoError(t, yaml.Unmarshal([]byte(test.config), d))
+			client := prepareMockClient()
+			client.containers[0].Image = "nginx:latest"
+			client.containers[0].Labels = map[string]string{
+				"com.docker.compose.project": "shop",
+				"com.docker.compose.service": "web",
+				"com.example.team":           "payments",
+			}
+			d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+			require.True(t, d.Init())
+			_ = d.Collect()
+
+			chart := d.Charts().Get("network_web_bytes")
+			require.NotNil(t, chart)
+			assert.Equal(t, test.wantLabels, chart.Labels)
+
+			chart = d.Charts().Get("network_web_interface_eth0_packets")
+			require.NotNil(t, chart)
+			assert.Equal(t, append(test.wantLabels, module.Label{Key: "interface", Value: "eth0"}), chart.Labels)
+		})
+	}
+}
+
+func TestDockerNetwork_Collect_NetnsMode(t *testing.T) {
+	d := New()
+	defer d.Cleanup()
+	d.Mode = modeNetns
+	d.ProcRoot = "testdata/proc"
+	client := prepareMockClient()
+	client.pids = map[string]int{"1234567890abcdef": 4242}
+	d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+	require.True(t, d.Init())
+
+	expected := map[string]int64{
+		"container_web_network_bytes_rx":          1000,
+		"container_web_network_bytes_tx":          500,
+		"container_web_interface_eth0_packets_rx": 10,
+		"container_web_interface_eth0_packets_tx": 4,
+		"container_web_interface_eth0_errors_rx":  1,
+		"container_web_interface_eth0_errors_tx":  0,
+		"container_web_interface_eth0_dropped_rx": 3,
+		"container_web_interface_eth0_dropped_tx": 2,
+		"host_network_bytes_rx":                   0,
+		"host_network_bytes_tx":                   0,
+		"docker_network_bridge_containers":        1,
+		"docker_network_bridge_bytes_rx":          0,
+		"docker_network_bridge_bytes_tx":          0,
+		"docker_network_host_containers":          0,
+		"docker_network_host_bytes_rx":            0,
+		"docker_network_host_bytes_tx":            0,
+		"containers_state_created":                0,
+		"containers_state_running":                1,
+		"containers_state_paused":                 0,
+		"containers_state_restarting":             0,
+		"containers_state_removing":               0,
+		"containers_state_exited":                 1,
+		"containers_state_dead":                   0,
+		"containers_health_status_healthy":        1,
+		"containers_health_status_unhealthy":      0,
+		"containers_health_status_starting":       0,
+		"containers_health_status_none":           1,
+		"networks":                                2,
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, expected, d.Collect())
+	}
+	// the PID is looked up once, the stats aren't requested at all
+	assert.Equal(t, 1, client.inspectCalls)
+	assert.Empty(t, client.statsCalls)
+
+	// the process is gone (stale PID), the PID is looked up again
+	d.pids.put("1234567890abcdef", containerProc{pid: 4243, startTime: 808821})
+	assert.Equal(t, expected, d.Collect())
+	assert.Equal(t, 2, client.inspectCalls)
+
+	// the PID is reused by another process, the PID is looked up again
+	d.pids.put("1234567890abcdef", containerProc{pid: 4242, startTime: 1})
+	assert.Equal(t, expected, d.Collect())
+	assert.Equal(t, 3, client.inspectCalls)
+	proc, ok := d.pids.get("1234567890abcdef")
+	require.True(t, ok)
+	assert.Equal(t, containerProc{pid: 4242, startTime: 808821}, proc)
+
+	// the container is restarted, the PID is looked up again
+	client.sendEvent(t, d, events.Message{Type: events.ContainerEventType, Action: "start", Actor: events.Actor{ID: "1234567890abcdef"}})
+	assert.Equal(t, expected, d.Collect())
+	assert.Equal(t, 4, client.inspectCalls)
+
+	// the container is gone, its PID is forgotten
+	client.containers = nil
+	d.WatchEvents = false
+	_ = d.Collect()
+	assert.Empty(t, d.pids.pids)
+}
+
+func TestDockerNetwork_Collect_NetnsModeSkipsSharedNamespaces(t *testing.T) {
+	d := New()
+	defer d.Cleanup()
+	d.Mode = modeNetns
+	d.ProcRoot = "testdata/proc"
+	client := prepareMockClient()
+	proxy := types.Container{ID: "abcdef1234567890", Names: []string{"/proxy"}, State: "running"}
+	proxy.HostConfig.NetworkMode = "host"
+	sidecar := types.Container{ID: "0987654321fedcba", Names: []string{"/sidecar"}, State: "running"}
+	sidecar.HostConfig.NetworkMode = "container:1234567890abcdef"
+	client.containers = append(client.containers, proxy, sidecar)
+	client.pids = map[string]int{"1234567890abcdef": 4242, "abcdef1234567890": 4242, "0987654321fedcba": 4242}
+	d.newClient = func(Config) (dockerClient, error) { return client, nil }
+
+	require.True(t, d.Init())
+
+	mx := d.Collect()
+
+	// only 'web' owns its network namespace, the traffic is accounted once
+	assert.Equal(t, map[string]bool{"web": true}, d.containers)
+	assert.Equal(t, 1, client.inspectCalls)
+	assert.Equal(t, int64(1000), mx["container_web_network_bytes_rx"])
+	assert.NotContains(t, mx, "container_proxy_network_bytes_rx")
+	assert.NotContains(t, mx, "container_sidecar_network_bytes_rx")
+}
+
+func TestReadProcStartTime(t *testing.T) {
+	startTime, err := readProcStartTime("testdata/proc/4242/stat")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(808821), startTime)
+
+	_, err = readProcStartTime("testdata/proc/4242/net/dev")
+	assert.Error(t, err)
+}
+
+func TestReadNetDev(t *testing.T) {
+	networks, err := readNetDev("testdata/proc/4242/net/dev")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]types.NetworkStats{
+		"eth0": {
+			RxBytes: 1000, RxPackets: 10, RxErrors: 1, RxDropped: 3,
+			TxBytes: 500, TxPackets: 4, TxErrors: 0, TxDropped: 2,
+		},
+	}, networks)
+
+	_, err = readNetDev("testdata/proc/1/net/dev")
+	assert.Error(t, err)
+}
+
+func prepareMockClient() *mockClient {
+	return &mockClient{
+		containers: []types.Container{
+			{
+				ID: "1234567890abcdef", Names: []string{"/web"}, State: "running", Status: "Up 2 hours (healthy)",
+				NetworkSettings: &types.SummaryNetworkSettings{
+					Networks: map[string]*network.EndpointSettings{"bridge": {}},
+				},
+			},
+			{ID: "fedcba0987654321", Names: []string{"/old"}, State: "exited", Status: "Exited (0) 3 days ago"},
+		},
+		networks: map[string]types.NetworkStats{
+			"eth0": {
+				RxBytes: 1000, RxPackets: 10, RxErrors: 1, RxDropped: 3,
+				TxBytes: 500, TxPackets: 4, TxErrors: 0, TxDropped: 2,
+			},
+		},
+		dockerNetworks: []types.NetworkResource{
+			{
+				ID: "bridge_id", Name: "bridge", Driver: "bridge", Scope: "local",
+				Containers: map[string]types.EndpointResource{
+					"1234567890abcdef": {Name: "web"},
+				},
+			},
+			{ID: "host_id", Name: "host", Driver: "host", Scope: "local"},
+		},
+	}
+}
+
+type mockClient struct {
+	containers         []types.Container
+	networks           map[string]types.NetworkStats
+	dockerNetworks     []types.NetworkResource
+	errOnContainerList bool
+	errOnNetworkList   bool
+	errOnEvents        bool
+	errOnPing          bool
+	apiVersion         string
+	negotiatedVersion  string
+	statsDelay         time.Duration
+	statsErrors        map[string]bool
+	mux                sync.Mutex
+	statsCalls         []string
+	inFlight           int
+	inspectCalls       int
+	pids               map[string]int
+	maxInFlight        int
+	listCalls          int
+	eventsCalls        int
+	events             chan events.Message
+	closeCalled        bool
+}
+
+func (m *mockClient) Ping(context.Context) (types.Ping, error) {
+	if m.errOnPing {
+		return types.Ping{}, errors.New("mockClient.Ping() error")
+	}
+	return types.Ping{APIVersion: m.apiVersion}, nil
+}
+
+func (m *mockClient) NegotiateAPIVersionPing(ping types.Ping) {
+	m.negotiatedVersion = ping.APIVersion
+}
+
+func (m *mockClient) Info(context.Context) (types.Info, error) {
+	return types.Info{}, nil
+}
+
+func (m *mockClient) ContainerList(context.Context, types.ContainerListOptions) ([]types.Container, error) {
+	m.listCalls++
+	if m.errOnContainerList {
+		return nil, errors.New("mockClient.ContainerList() error")
+	}
+	// the client returns a new list every time
+	return slices.Clone(m.containers), nil
+}
+
+func (m *mockClient) ContainerStats(_ context.Context, containerID string, _ bool) (types.ContainerStats, error) {
+	m.mux.Lock()
+	m.statsCalls = append(m.statsCalls, containerID)
+	m.inFlight++
+	m.maxInFlight = max(m.maxInFlight, m.inFlight)
+	m.mux.Unlock()
+
+	defer func() {
+		m.mux.Lock()
+		m.inFlight--
+		m.mux.Unlock()
+	}()
+
+	if m.statsDelay > 0 {
+		time.Sleep(m.statsDelay)
+	}
+	if m.statsErrors[containerID] {
+		return types.ContainerStats{}, errors.New("mockClient.ContainerStats() error")
+	}
+	networks := make(map[string]types.NetworkStats)
+	for name, net := range m.networks {
+		networks[name] = net
+	}
+	bs, err := json.Marshal(types.StatsJSON{Networks: networks})
+	if err != nil {
+		return types.ContainerStats{}, err
+	}
+	return types.ContainerStats{Body: io.NopCloser(bytes.NewReader(bs))}, nil
+}
+
+// addBytes increases the bytes counters of all the containers interfaces.
+func (m *mockClient) addBytes(rx, tx uint64) {
+	for name, net := range m.networks {
+		net.RxBytes += rx
+		net.TxBytes += tx
+		m.networks[name] = net
+	}
+}
+
+func (m *mockClient) NetworkList(context.Context, types.NetworkListOptions) ([]types.NetworkResource, error) {
+	if m.errOnNetworkList {
+		return nil, errors.New("mockClient.NetworkList() error")
+	}
+	var networks []types.NetworkResource
+	for _, nw := range m.dockerNetworks {
+		networks = append(networks, types.NetworkResource{ID: nw.ID, Name: nw.Name, Driver: nw.Driver, Scope: nw.Scope})
+	}
+	return networks, nil
+}
+
+func (m *mockClient) NetworkInspect(_ context.Context, networkID string, _ types.NetworkInspectOptions) (types.NetworkResource, error) {
+	for _, nw := range m.dockerNetworks {
+		if nw.ID == networkID {
+			return nw, nil
+		}
+	}
+	return types.NetworkResource{}, errors.New("mockClient.NetworkInspect() network not found")
+}
+
+func (m *mockClient) ContainerInspect(_ context.Context, containerID string) (types.ContainerJSON, error) {
+	m.mux.Lock()
+	m.inspectCalls++
+	m.mux.Unlock()
+
+	for _, c := range m.containers {
+		if c.ID == containerID {
+			return types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{
+					ID:    c.ID,
+					State: &types.ContainerState{Running: true, Pid: m.pids[c.ID]},
+				},
+			}, nil
+		}
+	}
+	return types.ContainerJSON{}, errors.New("mockClient.ContainerInspect() container not found")
+}
+
+func (m *mockClient) Events(context.Context, types.EventsOptions) (<-chan events.Message, <-chan error) {
+	m.eventsCalls++
+	errs := make(chan error, 1)
+	m.events = make(chan events.Message)
+	if m.errOnEvents {
+		errs <- errors.New("mockClient.Events() error")
+	}
+	return m.events, errs
+}
+
+// sendEvent sends the event to the module and waits until it is handled.
+func (m *mockClient) sendEvent(t *testing.T, d *DockerNetwork, msg events.Message) {
+	d.watcher.mux.Lock()
+	gen := d.watcher.gen
+	d.watcher.mux.Unlock()
+
+	m.events <- msg
+
+	require.Eventually(t, func() bool {
+		d.watcher.mux.Lock()
+		defer d.watcher.mux.Unlock()
+		return d.watcher.gen > gen
+	}, time.Second, time.Millisecond*10)
+}
+
+func (m *mockClient) Close() error {
+	m.closeCalled = true
+	return nil
+}
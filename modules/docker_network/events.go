@@ -0,0 +1,206 @@
+package docker_network
+
+import (
+	"context"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"sync"
+	"time"
+)
+
+// containerWatcher keeps the set of running containers up to date using the docker events stream.
+// When the stream is not available the containers are listed on every collection (poll-based fallback).
+type containerWatcher struct {
+	mux sync.Mutex
+
+	running       bool
+	dirty         bool
+	gen           uint64 // incremented on every event, used to detect events that arrived during a reconciliation
+	lastReconcile time.Time
+	containers    map[string]types.Container // [containerID]
+	died          []string                   // names of the containers that died since the last collection
+	restarted     []string                   // IDs of the containers that started or died since the last collection
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newContainerWatcher() *containerWatcher {
+	return &containerWatcher{
+		dirty:      true,
+		containers: make(map[string]types.Container),
+	}
+}
+
+// start subscribes to the container events if there is no active subscription.
+func (w *containerWatcher) start(client dockerClient, log func(format string, v ...any)) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if w.running {
+		return
+	}
+	if w.cancel != nil {
+		w.cancel()
+	}
+	// The previous subscription is gone, we could have missed some events
+	w.dirty = true
+	w.running = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	opts := types.EventsOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", events.ContainerEventType),
+			filters.Arg("event", "start"),
+			filters.Arg("event", "die"),
+			filters.Arg("event", "destroy"),
+			filters.Arg("event", "rename"),
+			filters.Arg("event", "pause"),
+			filters.Arg("event", "unpause"),
+			filters.Arg("event", "health_status"),
+		),
+	}
+	msgs, errs := client.Events(ctx, opts)
+
+	go func(done chan struct{}) {
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-errs:
+				if err != nil && ctx.Err() == nil {
+					log("docker events stream: %v, falling back to polling", err)
+				}
+				w.mux.Lock()
+				w.running = false
+				w.dirty = true
+				w.mux.Unlock()
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					w.mux.Lock()
+					w.running = false
+					w.dirty = true
+					w.mux.Unlock()
+					return
+				}
+				w.handleEvent(msg)
+			}
+		}
+	}(w.done)
+}
+
+func (w *containerWatcher) stop() {
+	w.mux.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.mux.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+
+	w.mux.Lock()
+	w.running = false
+	w.mux.Unlock()
+}
+
+func (w *containerWatcher) handleEvent(msg events.Message) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	w.gen++
+
+	switch msg.Action {
+	case "start", "die", "destroy":
+		// The container main process is changed, the cached PID ('netns' mode) is stale
+		w.restarted = append(w.restarted, msg.Actor.ID)
+	}
+
+	switch msg.Action {
+	case "die":
+		// The stopped containers are kept for the containers state summary
+		if c, ok := w.containers[msg.Actor.ID]; ok {
+			c.State, c.Status = "exited", "Exited"
+			w.containers[msg.Actor.ID] = c
+			w.died = append(w.died, containerName(c))
+		}
+	case "destroy":
+		if c, ok := w.containers[msg.Actor.ID]; ok {
+			delete(w.containers, msg.Actor.ID)
+			w.died = append(w.died, containerName(c))
+		}
+	case "rename":
+		if c, ok := w.containers[msg.Actor.ID]; ok {
+			w.died = append(w.died, containerName(c))
+		}
+		w.dirty = true
+	default:
+		// The event has no full container info (e.g. the list of names or the status), we need to list the containers
+		// 'start', 'pause', 'unpause', 'health_status: <status>'
+		w.dirty = true
+	}
+}
+
+// needList returns true if the container set can't be trusted and the containers need to be listed.
+// The returned generation should be passed to reconcile.
+func (w *containerWatcher) needList(reconcileEvery time.Duration, now time.Time) (bool, uint64) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if !w.running || w.dirty {
+		return true, w.gen
+	}
+	return reconcileEvery > 0 && now.Sub(w.lastReconcile) >= reconcileEvery, w.gen
+}
+
+// reconcile replaces the container set with the result of ContainerList.
+// If there were events after the list was requested, the set stays dirty and will be listed again.
+func (w *containerWatcher) reconcile(containers []types.Container, gen uint64, now time.Time) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	w.dirty = w.gen != gen
+	w.lastReconcile = now
+	w.containers = make(map[string]types.Container, len(containers))
+	for _, c := range containers {
+		w.containers[c.ID] = c
+	}
+}
+
+func (w *containerWatcher) list() []types.Container {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	containers := make([]types.Container, 0, len(w.containers))
+	for _, c := range w.containers {
+		containers = append(containers, c)
+	}
+	return containers
+}
+
+// takeDied returns the names of the containers that died since the last call.
+func (w *containerWatcher) takeDied() []string {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	died := w.died
+	w.died = nil
+	return died
+}
+
+// takeRestarted returns the IDs of the containers that started or died since the last call.
+func (w *containerWatcher) takeRestarted() []string {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	restarted := w.restarted
+	w.restarted = nil
+	return restarted
+}
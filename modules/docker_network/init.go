@@ -0,0 +1,83 @@
+package docker_network
+
+import (
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/netdata/go.d.plugin/pkg/matcher"
+	"strings"
+)
+
+func (d *DockerNetwork) validateConfig() error {
+	switch d.Mode {
+	case "", modeAPI:
+	case modeNetns:
+		if d.ProcRoot == "" {
+			return errors.New("'proc_root' not set")
+		}
+	default:
+		return fmt.Errorf("unknown mode '%s', valid modes are '%s' and '%s'", d.Mode, modeAPI, modeNetns)
+	}
+	return nil
+}
+
+// containerFilter decides which containers are collected, a nil matcher matches everything.
+type containerFilter struct {
+	name  matcher.Matcher
+	label matcher.Matcher
+	image matcher.Matcher
+}
+
+func (d *DockerNetwork) initContainerFilter() (*containerFilter, error) {
+	var f containerFilter
+	var err error
+
+	if f.name, err = newSelector(d.ContainerSelector); err != nil {
+		return nil, fmt.Errorf("container_selector: %v", err)
+	}
+	if f.label, err = newSelector(d.LabelSelector); err != nil {
+		return nil, fmt.Errorf("label_selector: %v", err)
+	}
+	if f.image, err = newSelector(d.ImageSelector); err != nil {
+		return nil, fmt.Errorf("image_selector: %v", err)
+	}
+
+	return &f, nil
+}
+
+func newSelector(expr string) (matcher.Matcher, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	return matcher.NewSimplePatternsMatcher(expr)
+}
+
+// match checks the container against all the selectors.
+// The label selector is matched against 'key=value' pairs, one matching label is enough.
+func (f *containerFilter) match(container types.Container) bool {
+	if f == nil {
+		return true
+	}
+	if f.name != nil && !f.name.MatchString(containerName(container)) {
+		return false
+	}
+	if f.image != nil && !f.image.MatchString(container.Image) {
+		return false
+	}
+	if f.label != nil {
+		for k, v := range container.Labels {
+			if f.label.MatchString(k + "=" + v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func containerName(container types.Container) string {
+	if len(container.Names) == 0 {
+		return container.ID
+	}
+	return strings.TrimPrefix(container.Names[0], "/")
+}
@@ -0,0 +1,199 @@
+package docker_network
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	modeAPI   = "api"
+	modeNetns = "netns"
+)
+
+// pidCache holds the main processes of the containers, [containerID]process.
+type pidCache struct {
+	mux  sync.Mutex
+	pids map[string]containerProc
+}
+
+// containerProc is the container main process. The PID can be reused by another process after the container
+// is stopped or restarted, the start time is used to detect it.
+type containerProc struct {
+	pid       int
+	startTime uint64
+}
+
+func newPIDCache() *pidCache {
+	return &pidCache{pids: make(map[string]containerProc)}
+}
+
+func (c *pidCache) get(id string) (containerProc, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	proc, ok := c.pids[id]
+	return proc, ok
+}
+
+func (c *pidCache) put(id string, proc containerProc) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.pids[id] = proc
+}
+
+func (c *pidCache) remove(id string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	delete(c.pids, id)
+}
+
+// retain removes the PIDs of the containers that are not in the list.
+func (c *pidCache) retain(containers []types.Container) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	ids := make(map[string]bool, len(containers))
+	for _, container := range containers {
+		ids[container.ID] = true
+	}
+	for id := range c.pids {
+		if !ids[id] {
+			delete(c.pids, id)
+		}
+	}
+}
+
+// ownsNetns reports whether the container has its own network namespace. A container in the host network mode
+// shares the host namespace and a container in the 'container:<name|id>' mode shares the namespace of another
+// container, reading their /proc/<pid>/net/dev would account the same traffic more than once.
+func ownsNetns(container types.Container) bool {
+	mode := container.HostConfig.NetworkMode
+	return mode != "host" && !strings.HasPrefix(mode, "container:")
+}
+
+// fetchContainerNetDev reads the network stats of the container from its network namespace (/proc/<pid>/net/dev).
+// The daemon is asked only for the PID, once per container start.
+func (d *DockerNetwork) fetchContainerNetDev(container types.Container) (types.StatsJSON, error) {
+	procDir := func(pid int) string { return filepath.Join(d.ProcRoot, strconv.Itoa(pid)) }
+
+	proc, ok := d.pids.get(container.ID)
+	if ok {
+		// The container was restarted (or stopped) and the PID belongs to another process now
+		if startTime, err := readProcStartTime(filepath.Join(procDir(proc.pid), "stat")); err != nil || startTime != proc.startTime {
+			d.pids.remove(container.ID)
+			ok = false
+		}
+	}
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), d.Timeout.Duration)
+		defer cancel()
+
+		info, err := d.client.ContainerInspect(ctx, container.ID)
+		if err != nil {
+			return types.StatsJSON{}, err
+		}
+		if info.State == nil || info.State.Pid == 0 {
+			return types.StatsJSON{}, errors.New("container has no running process")
+		}
+		startTime, err := readProcStartTime(filepath.Join(procDir(info.State.Pid), "stat"))
+		if err != nil {
+			return types.StatsJSON{}, err
+		}
+		proc = containerProc{pid: info.State.Pid, startTime: startTime}
+		d.pids.put(container.ID, proc)
+	}
+
+	networks, err := readNetDev(filepath.Join(procDir(proc.pid), "net", "dev"))
+	if err != nil {
+		// The process is gone (e.g. the container was restarted), we need to ask for the PID again
+		d.pids.remove(container.ID)
+		return types.StatsJSON{}, err
+	}
+
+	return types.StatsJSON{Networks: networks}, nil
+}
+
+// readProcStartTime returns the process start time (in clock ticks after the system boot) from /proc/<pid>/stat.
+func readProcStartTime(path string) (uint64, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	// The command name can contain spaces and parentheses, the fields after it start with the state (3rd field)
+	i := strings.LastIndexByte(string(bs), ')')
+	if i < 0 {
+		return 0, fmt.Errorf("unexpected '%s' data", path)
+	}
+	fields := strings.Fields(string(bs[i+1:]))
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("unexpected '%s' data", path)
+	}
+
+	// starttime is the 22nd field
+	startTime, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected '%s' data: %v", path, err)
+	}
+	return startTime, nil
+}
+
+// readNetDev parses /proc/net/dev, the loopback interface is skipped (the docker stats don't include it).
+func readNetDev(path string) (map[string]types.NetworkStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	networks := make(map[string]types.NetworkStats)
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// Interface: rx bytes packets errs drop fifo frame compressed multicast tx bytes packets errs drop fifo colls carrier compressed
+		iface, data, ok := strings.Cut(sc.Text(), ":")
+		if !ok {
+			// Header lines
+			continue
+		}
+		iface = strings.TrimSpace(iface)
+		if iface == "lo" {
+			continue
+		}
+
+		fields := strings.Fields(data)
+		if len(fields) < 16 {
+			return nil, fmt.Errorf("unexpected '%s' data: '%s'", path, sc.Text())
+		}
+
+		var nums [16]uint64
+		for i := range nums {
+			if nums[i], err = strconv.ParseUint(fields[i], 10, 64); err != nil {
+				return nil, fmt.Errorf("unexpected '%s' data: '%s'", path, sc.Text())
+			}
+		}
+
+		networks[iface] = types.NetworkStats{
+			RxBytes:   nums[0],
+			RxPackets: nums[1],
+			RxErrors:  nums[2],
+			RxDropped: nums[3],
+			TxBytes:   nums[8],
+			TxPackets: nums[9],
+			TxErrors:  nums[10],
+			TxDropped: nums[11],
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return networks, nil
+}